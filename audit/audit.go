@@ -0,0 +1,183 @@
+// Package audit records who did what to which resource, with before and after diffs, in a hash chained trail
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/authz/jwtauthz"
+	"github.com/muhammad-fakhri/go-libs/log"
+)
+
+var (
+	ErrMissingActor = errors.New("audit: missing actor, use jwtauthz.WithClaims or Action.Actor")
+	ErrClosed       = errors.New("audit: auditor closed")
+)
+
+//go:generate mockgen -destination mock_audit/audit.go -package mock_audit -source audit.go
+
+type Auditor interface {
+	// Record appends action to the trail and writes it to every sink
+	Record(ctx context.Context, action Action) (*Record, error)
+	// Flush writes records buffered by the sinks
+	Flush() error
+	// Close flushes and closes every sink
+	Close() error
+}
+
+// Action is what happened, Before and After are any JSON encodable value, e.g. the resource before and after update
+type Action struct {
+	Name       string // e.g. "template.update"
+	Resource   string // e.g. "template"
+	ResourceID string
+	Before     interface{}
+	After      interface{}
+	Metadata   map[string]string
+	// Actor overrides the actor taken from the JWT claims of ctx, e.g. for scheduled jobs
+	Actor *Actor
+}
+
+type Actor struct {
+	ID    int    `json:"id"`
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+}
+
+// Record is one entry of the trail. Hash covers every other field including PrevHash,
+// so editing or removing a record breaks the chain, see Verify.
+type Record struct {
+	Sequence   uint64            `json:"sequence"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Actor      Actor             `json:"actor"`
+	Action     string            `json:"action"`
+	Resource   string            `json:"resource"`
+	ResourceID string            `json:"resource_id,omitempty"`
+	Changes    []Change          `json:"changes,omitempty"`
+	Country    string            `json:"country,omitempty"`
+	ContextID  string            `json:"context_id,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash"`
+}
+
+type auditor struct {
+	sinks []Sink
+	clock func() time.Time
+
+	mu       sync.Mutex
+	sequence uint64
+	lastHash string
+	closed   bool
+}
+
+// NewAuditor returns auditor writing records to sinks, records are chained from the genesis hash unless WithChainHead is given
+func NewAuditor(sinks []Sink, options ...Option) Auditor {
+	c := &config{clock: time.Now}
+	for _, o := range options {
+		o(c)
+	}
+
+	return &auditor{
+		sinks:    sinks,
+		clock:    c.clock,
+		sequence: c.sequence,
+		lastHash: c.lastHash,
+	}
+}
+
+func (a *auditor) Record(ctx context.Context, action Action) (*Record, error) {
+	actor, ok := actorFromContext(ctx, action.Actor)
+	if !ok {
+		return nil, ErrMissingActor
+	}
+
+	changes, err := Diff(action.Before, action.After)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{
+		Actor:      actor,
+		Action:     action.Name,
+		Resource:   action.Resource,
+		ResourceID: action.ResourceID,
+		Changes:    changes,
+		Metadata:   action.Metadata,
+	}
+	if data, ok := ctx.Value(log.ContextDataMapKey).(map[string]string); ok {
+		record.Country = data[log.ContextCountryKey]
+		record.ContextID = data[log.ContextIdKey]
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil, ErrClosed
+	}
+
+	record.Sequence = a.sequence + 1
+	record.Timestamp = a.clock().UTC()
+	record.PrevHash = a.lastHash
+	if record.Hash, err = record.computeHash(); err != nil {
+		return nil, err
+	}
+
+	// the chain moves on even when a sink fails, Verify reports the gap of that sink
+	a.sequence, a.lastHash = record.Sequence, record.Hash
+
+	// sinks are written under the lock so every sink keeps the chain order
+	var firstErr error
+	for _, sink := range a.sinks {
+		if err := sink.Write(record); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("audit: write record %d: %v", record.Sequence, err)
+		}
+	}
+	return record, firstErr
+}
+
+func (a *auditor) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var firstErr error
+	for _, sink := range a.sinks {
+		if err := sink.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (a *auditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+
+	var firstErr error
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func actorFromContext(ctx context.Context, override *Actor) (Actor, bool) {
+	if override != nil {
+		return *override, true
+	}
+
+	claims, ok := jwtauthz.ClaimsFromContext(ctx)
+	if !ok {
+		return Actor{}, false
+	}
+	return Actor{ID: claims.ID, Email: claims.Email, Name: claims.Name}, true
+}
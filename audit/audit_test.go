@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/authz/jwtauthz"
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type memorySink struct {
+	records []*Record
+	err     error
+	closed  bool
+}
+
+func (s *memorySink) Write(record *Record) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memorySink) Flush() error { return nil }
+
+func (s *memorySink) Close() error {
+	s.closed = true
+	return nil
+}
+
+type template struct {
+	Name     string   `json:"name"`
+	Channels []string `json:"channels"`
+	Retry    int      `json:"retry"`
+}
+
+func adminContext() context.Context {
+	ctx := jwtauthz.WithClaims(context.Background(), &jwtauthz.JwtClaims{ID: 7, Email: "admin@example.com", Name: "admin"})
+	return log.WithContextData(ctx, map[string]string{log.ContextCountryKey: "ID", log.ContextIdKey: "ctx-1"})
+}
+
+func TestAuditorRecord(t *testing.T) {
+	sink := &memorySink{}
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	auditor := NewAuditor([]Sink{sink}, WithClock(func() time.Time { return now }))
+
+	record, err := auditor.Record(adminContext(), Action{
+		Name:       "template.update",
+		Resource:   "template",
+		ResourceID: "42",
+		Before:     template{Name: "promo", Channels: []string{"email"}, Retry: 1},
+		After:      template{Name: "promo", Channels: []string{"email", "push"}, Retry: 3},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), record.Sequence)
+	assert.Equal(t, now, record.Timestamp)
+	assert.Equal(t, Actor{ID: 7, Email: "admin@example.com", Name: "admin"}, record.Actor)
+	assert.Equal(t, "ID", record.Country)
+	assert.Equal(t, "ctx-1", record.ContextID)
+	assert.Equal(t, []string{"channels.1", "retry"}, paths(record.Changes))
+	assert.Equal(t, "", record.PrevHash)
+	assert.Equal(t, 64, len(record.Hash))
+
+	next, err := auditor.Record(context.Background(), Action{Name: "template.delete", Resource: "template", Actor: &Actor{Name: "scheduler"}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), next.Sequence)
+	assert.Equal(t, record.Hash, next.PrevHash)
+
+	assert.Nil(t, Verify(sink.records))
+	assert.Nil(t, auditor.Close())
+	assert.True(t, sink.closed)
+
+	_, err = auditor.Record(adminContext(), Action{Name: "template.update"})
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestAuditorMissingActor(t *testing.T) {
+	sink := &memorySink{}
+	auditor := NewAuditor([]Sink{sink})
+
+	_, err := auditor.Record(context.Background(), Action{Name: "template.update"})
+	assert.Equal(t, ErrMissingActor, err)
+	assert.Empty(t, sink.records)
+}
+
+func TestAuditorChainHeadAndSinkFailure(t *testing.T) {
+	failing := &memorySink{err: errors.New("disk full")}
+	working := &memorySink{}
+	auditor := NewAuditor([]Sink{failing, working}, WithChainHead(10, "abc"))
+
+	record, err := auditor.Record(adminContext(), Action{Name: "user.ban"})
+	assert.EqualError(t, err, "audit: write record 11: disk full")
+	assert.Equal(t, "abc", record.PrevHash)
+	assert.Equal(t, 1, len(working.records))
+
+	failing.err = nil
+	_, err = auditor.Record(adminContext(), Action{Name: "user.unban"})
+	assert.Nil(t, err)
+	assert.Nil(t, Verify(working.records))
+	assert.Nil(t, Verify(failing.records))
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	sink := &memorySink{}
+	auditor := NewAuditor([]Sink{sink})
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := auditor.Record(adminContext(), Action{Name: name})
+		assert.Nil(t, err)
+	}
+
+	edited := *sink.records[1]
+	edited.Actor.ID = 1
+	assert.EqualError(t, Verify([]*Record{sink.records[0], &edited, sink.records[2]}), "audit: record 2 was modified")
+	assert.EqualError(t, Verify([]*Record{sink.records[0], sink.records[2]}), "audit: record 3 follows record 1")
+
+	rehashed := edited
+	rehashed.Hash, _ = rehashed.computeHash()
+	assert.EqualError(t, Verify([]*Record{sink.records[0], &rehashed, sink.records[2]}), "audit: record 3 is not chained to record 2")
+}
+
+func paths(changes []Change) []string {
+	var result []string
+	for _, change := range changes {
+		result = append(result, change.Path)
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxRecordSize bounds a single NDJSON line read by VerifyNDJSON
+const maxRecordSize = 10 * 1024 * 1024
+
+// computeHash returns sha256 of the record encoded without its hash
+func (r *Record) computeHash() (string, error) {
+	unhashed := *r
+	unhashed.Hash = ""
+
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", fmt.Errorf("audit: encode record %d: %v", r.Sequence, err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks records are an unbroken chain: every hash matches its record, links to the previous record
+// and sequences are consecutive. The first record may continue a chain written before, see WithChainHead.
+// The hashes are not keyed, so a rewritten or truncated trail still verifies. Compare the first and last
+// records with a head and last hash anchored outside the trail to detect that.
+func Verify(records []*Record) error {
+	for i, record := range records {
+		hash, err := record.computeHash()
+		if err != nil {
+			return err
+		}
+		if hash != record.Hash {
+			return fmt.Errorf("audit: record %d was modified", record.Sequence)
+		}
+
+		if i == 0 {
+			if record.Sequence == 1 && record.PrevHash != "" {
+				return fmt.Errorf("audit: record 1 does not start the chain")
+			}
+			continue
+		}
+
+		prev := records[i-1]
+		if record.Sequence != prev.Sequence+1 {
+			return fmt.Errorf("audit: record %d follows record %d", record.Sequence, prev.Sequence)
+		}
+		if record.PrevHash != prev.Hash {
+			return fmt.Errorf("audit: record %d is not chained to record %d", record.Sequence, prev.Sequence)
+		}
+	}
+	return nil
+}
+
+// VerifyNDJSON reads records written by FileSink or StorageSink and checks them with Verify
+func VerifyNDJSON(r io.Reader) error {
+	records, err := ReadNDJSON(r)
+	if err != nil {
+		return err
+	}
+	return Verify(records)
+}
+
+// ReadNDJSON decodes one record per line, numbers in changes are kept as json.Number so hashes can be verified
+func ReadNDJSON(r io.Reader) ([]*Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+
+	var records []*Record
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+
+		record := &Record{}
+		if err := decoder.Decode(record); err != nil {
+			return nil, fmt.Errorf("audit: decode line %d: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit: read records: %v", err)
+	}
+	return records, nil
+}
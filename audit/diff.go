@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Change is a value changed between Action.Before and Action.After.
+// Path is dotted, with array indexes, e.g. "channels.0.name". Before or After is nil when the value was added or removed.
+type Change struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff compares JSON representations of before and after, changes are sorted by path
+func Diff(before, after interface{}) ([]Change, error) {
+	b, err := normalize(before)
+	if err != nil {
+		return nil, fmt.Errorf("audit: encode before: %v", err)
+	}
+	a, err := normalize(after)
+	if err != nil {
+		return nil, fmt.Errorf("audit: encode after: %v", err)
+	}
+
+	var changes []Change
+	diff("", b, a, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// normalize decodes the JSON of value, keeping numbers as json.Number so they are hashed as written
+func normalize(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var normalized interface{}
+	err = decoder.Decode(&normalized)
+	return normalized, err
+}
+
+func diff(path string, before, after interface{}, changes *[]Change) {
+	// created and deleted objects are listed field by field
+	if _, ok := after.(map[string]interface{}); ok && before == nil {
+		before = map[string]interface{}{}
+	}
+	if _, ok := before.(map[string]interface{}); ok && after == nil {
+		after = map[string]interface{}{}
+	}
+
+	switch b := before.(type) {
+	case map[string]interface{}:
+		if a, ok := after.(map[string]interface{}); ok {
+			for key, value := range b {
+				diff(join(path, key), value, a[key], changes)
+			}
+			for key, value := range a {
+				if _, ok := b[key]; !ok {
+					diff(join(path, key), nil, value, changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if a, ok := after.([]interface{}); ok {
+			for i := 0; i < len(b) || i < len(a); i++ {
+				var bv, av interface{}
+				if i < len(b) {
+					bv = b[i]
+				}
+				if i < len(a) {
+					av = a[i]
+				}
+				diff(join(path, strconv.Itoa(i)), bv, av, changes)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, Change{Path: path, Before: before, After: after})
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
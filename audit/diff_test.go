@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{
+		"name":    "promo",
+		"limit":   10,
+		"tags":    []string{"a", "b"},
+		"owner":   map[string]interface{}{"id": 1, "team": "growth"},
+		"removed": true,
+	}
+	after := map[string]interface{}{
+		"name":  "promo",
+		"limit": 20,
+		"tags":  []string{"a"},
+		"owner": map[string]interface{}{"id": 1, "team": "core"},
+		"added": "x",
+	}
+
+	changes, err := Diff(before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{
+		{Path: "added", Before: nil, After: "x"},
+		{Path: "limit", Before: json.Number("10"), After: json.Number("20")},
+		{Path: "owner.team", Before: "growth", After: "core"},
+		{Path: "removed", Before: true, After: nil},
+		{Path: "tags.1", Before: "b", After: nil},
+	}, changes)
+}
+
+func TestDiffCreateAndDelete(t *testing.T) {
+	created, err := Diff(nil, template{Name: "promo", Channels: []string{"email"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"channels", "name", "retry"}, paths(created))
+	assert.Equal(t, []interface{}{"email"}, created[0].After)
+	assert.Equal(t, "promo", created[1].After)
+
+	deleted, err := Diff(template{Name: "promo", Channels: []string{"email"}}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(deleted))
+
+	unchanged, err := Diff(template{Name: "promo"}, template{Name: "promo"})
+	assert.Nil(t, err)
+	assert.Empty(t, unchanged)
+
+	_, err = Diff(make(chan int), nil)
+	assert.NotNil(t, err)
+}
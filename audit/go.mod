@@ -0,0 +1,14 @@
+module github.com/muhammad-fakhri/go-libs/audit
+
+go 1.13
+
+require (
+	github.com/golang/mock v1.4.3
+	github.com/muhammad-fakhri/go-libs/authz v1.1.0
+	github.com/muhammad-fakhri/go-libs/log v1.1.0
+	github.com/muhammad-fakhri/go-libs/messaging v1.0.0
+	github.com/muhammad-fakhri/go-libs/storage v1.0.0
+	github.com/sirupsen/logrus v1.4.2
+	github.com/stretchr/testify v1.7.1
+	gopkg.in/yaml.v2 v2.2.3 // indirect
+)
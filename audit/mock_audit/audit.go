@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: audit.go
+
+// Package mock_audit is a generated GoMock package.
+package mock_audit
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	audit "github.com/muhammad-fakhri/go-libs/audit"
+)
+
+// MockAuditor is a mock of Auditor interface.
+type MockAuditor struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditorMockRecorder
+}
+
+// MockAuditorMockRecorder is the mock recorder for MockAuditor.
+type MockAuditorMockRecorder struct {
+	mock *MockAuditor
+}
+
+// NewMockAuditor creates a new mock instance.
+func NewMockAuditor(ctrl *gomock.Controller) *MockAuditor {
+	mock := &MockAuditor{ctrl: ctrl}
+	mock.recorder = &MockAuditorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditor) EXPECT() *MockAuditorMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockAuditor) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockAuditorMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAuditor)(nil).Close))
+}
+
+// Flush mocks base method.
+func (m *MockAuditor) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockAuditorMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockAuditor)(nil).Flush))
+}
+
+// Record mocks base method.
+func (m *MockAuditor) Record(ctx context.Context, action audit.Action) (*audit.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, action)
+	ret0, _ := ret[0].(*audit.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditorMockRecorder) Record(ctx, action interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditor)(nil).Record), ctx, action)
+}
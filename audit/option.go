@@ -0,0 +1,27 @@
+package audit
+
+import "time"
+
+// Option configures Auditor
+type Option func(c *config)
+
+type config struct {
+	clock    func() time.Time
+	sequence uint64
+	lastHash string
+}
+
+// WithChainHead continues the chain after the last written record, e.g. after a restart
+func WithChainHead(sequence uint64, hash string) Option {
+	return func(c *config) {
+		c.sequence = sequence
+		c.lastHash = hash
+	}
+}
+
+// WithClock replaces time.Now for record timestamps
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Sink stores records in the order they are written
+type Sink interface {
+	Write(record *Record) error
+	// Flush stores records buffered by the sink
+	Flush() error
+	// Close flushes and releases the sink
+	Close() error
+}
+
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink appends records to path as NDJSON, one record per line, see VerifyNDJSON
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: open %s: %v", path, err)
+	}
+	return &fileSink{file: file}, nil
+}
+
+func (s *fileSink) Write(record *Record) error {
+	line, err := encodeLine(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(line)
+	return err
+}
+
+// Flush syncs the file to disk
+func (s *fileSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Sync()
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+func encodeLine(record *Record) ([]byte, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("audit: encode record %d: %v", record.Sequence, err)
+	}
+	return append(line, '\n'), nil
+}
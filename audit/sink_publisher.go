@@ -0,0 +1,25 @@
+package audit
+
+import "github.com/muhammad-fakhri/go-libs/messaging/pubsub"
+
+type publisherSink struct {
+	publisher pubsub.Publisher
+	topic     string
+}
+
+// NewPublisherSink publishes every record to topic, consumers can check the chain with Verify
+func NewPublisherSink(publisher pubsub.Publisher, topic string) Sink {
+	return &publisherSink{publisher: publisher, topic: topic}
+}
+
+func (s *publisherSink) Write(record *Record) error {
+	return s.publisher.Publish(s.topic, record)
+}
+
+func (s *publisherSink) Flush() error {
+	return nil
+}
+
+func (s *publisherSink) Close() error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/storage"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Minute
+)
+
+type StorageSinkConfig struct {
+	Prefix        string        // object path prefix, e.g. "audit/admin-api", default value: "audit"
+	BatchSize     int           // records per object, default value: 100
+	FlushInterval time.Duration // writes a partial batch after the interval, default value: 1 minute
+	Logger        log.SLogger   // logs errors of the interval flush, default value: log.NewSLogger("audit")
+}
+
+type storageSink struct {
+	store  storage.Storage
+	config StorageSinkConfig
+	// started is in every object name, so a process restarting the chain at sequence 1 never overwrites objects
+	started int64
+
+	mu      sync.Mutex
+	pending []*Record
+	buf     bytes.Buffer
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// NewStorageSink writes batches of records as NDJSON objects named "<prefix>/<start>-<first sequence>-<last sequence>.ndjson",
+// start being the unix nano time the sink was created. Listing the objects by name returns the chains of every
+// process start in order. A failed batch is kept and retried with the next one.
+func NewStorageSink(store storage.Storage, config StorageSinkConfig) Sink {
+	if config.Prefix == "" {
+		config.Prefix = "audit"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	if config.Logger == nil {
+		config.Logger = log.NewSLogger("audit")
+	}
+
+	s := &storageSink{
+		store:   store,
+		config:  config,
+		started: time.Now().UnixNano(),
+		done:    make(chan struct{}),
+	}
+	s.stopped.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *storageSink) run() {
+	defer s.stopped.Done()
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				s.config.Logger.Errorf(context.Background(), "%v, retried with the next flush", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *storageSink) Write(record *Record) error {
+	line, err := encodeLine(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, record)
+	s.buf.Write(line)
+	if len(s.pending) < s.config.BatchSize {
+		return nil
+	}
+	return s.flushLocked()
+}
+
+func (s *storageSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+func (s *storageSink) flushLocked() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	first, last := s.pending[0].Sequence, s.pending[len(s.pending)-1].Sequence
+	name := fmt.Sprintf("%s/%020d-%020d-%020d.ndjson", strings.TrimSuffix(s.config.Prefix, "/"), s.started, first, last)
+
+	writer, err := s.store.Write(name)
+	if err != nil {
+		return fmt.Errorf("audit: open %s: %v", name, err)
+	}
+	if _, err := writer.Write(s.buf.Bytes()); err != nil {
+		writer.Close()
+		return fmt.Errorf("audit: write %s: %v", name, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("audit: write %s: %v", name, err)
+	}
+
+	s.pending = nil
+	s.buf.Reset()
+	return nil
+}
+
+func (s *storageSink) Close() error {
+	close(s.done)
+	s.stopped.Wait()
+	return s.Flush()
+}
@@ -0,0 +1,192 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/muhammad-fakhri/go-libs/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryObject struct {
+	bytes.Buffer
+	store *memoryStorage
+	name  string
+}
+
+func (o *memoryObject) Close() error {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+	o.store.objects[o.name] = o.String()
+	return nil
+}
+
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string]string
+	err     error
+}
+
+func (s *memoryStorage) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *memoryStorage) Write(filepath string, options ...storage.Option) (io.WriteCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &memoryObject{store: s, name: filepath}, nil
+}
+
+func (s *memoryStorage) Read(filepath string, options ...storage.Option) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(s.objects[filepath])), nil
+}
+
+func (s *memoryStorage) Close() error { return nil }
+
+func (s *memoryStorage) Exists(filepath string) bool {
+	_, ok := s.objects[filepath]
+	return ok
+}
+
+func (s *memoryStorage) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name := range s.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type memoryPublisher struct {
+	topic    string
+	messages []interface{}
+}
+
+func (p *memoryPublisher) Publish(topic string, message interface{}) error {
+	p.topic = topic
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.ndjson")
+
+	sink, err := NewFileSink(path)
+	assert.Nil(t, err)
+	auditor := NewAuditor([]Sink{sink})
+	for _, retry := range []int{1, 2} {
+		_, err := auditor.Record(adminContext(), Action{Name: "template.update", Before: template{}, After: template{Retry: retry}})
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, auditor.Close())
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "\n"))
+	assert.Nil(t, VerifyNDJSON(bytes.NewReader(content)))
+
+	tampered := strings.Replace(string(content), `"after":2`, `"after":5`, 1)
+	assert.EqualError(t, VerifyNDJSON(strings.NewReader(tampered)), "audit: record 2 was modified")
+
+	_, err = NewFileSink(filepath.Join(dir, "missing", "audit.ndjson"))
+	assert.NotNil(t, err)
+}
+
+func TestStorageSink(t *testing.T) {
+	store := &memoryStorage{objects: map[string]string{}}
+	sink := NewStorageSink(store, StorageSinkConfig{Prefix: "audit/admin/", BatchSize: 2, FlushInterval: time.Hour})
+	started := fmt.Sprintf("%020d", sink.(*storageSink).started)
+	auditor := NewAuditor([]Sink{sink})
+
+	for i := 0; i < 3; i++ {
+		_, err := auditor.Record(adminContext(), Action{Name: "template.update"})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, []string{"audit/admin/" + started + "-00000000000000000001-00000000000000000002.ndjson"}, store.names())
+
+	store.setErr(errors.New("unavailable"))
+	assert.EqualError(t, auditor.Flush(), "audit: open audit/admin/"+started+"-00000000000000000003-00000000000000000003.ndjson: unavailable")
+
+	store.setErr(nil)
+	_, err := auditor.Record(adminContext(), Action{Name: "template.update"})
+	assert.Nil(t, err)
+	assert.Nil(t, auditor.Close())
+
+	names := store.names()
+	assert.Equal(t, 2, len(names))
+	var all strings.Builder
+	for _, name := range names {
+		all.WriteString(store.objects[name])
+	}
+	assert.Nil(t, VerifyNDJSON(strings.NewReader(all.String())))
+}
+
+func TestStorageSinkRestart(t *testing.T) {
+	store := &memoryStorage{objects: map[string]string{}}
+
+	// both processes start the chain at sequence 1, the objects of the first are kept
+	for i := 0; i < 2; i++ {
+		sink := NewStorageSink(store, StorageSinkConfig{FlushInterval: time.Hour})
+		_, err := NewAuditor([]Sink{sink}).Record(adminContext(), Action{Name: "template.update"})
+		assert.Nil(t, err)
+		assert.Nil(t, sink.Close())
+	}
+
+	names := store.names()
+	assert.Equal(t, 2, len(names))
+	for _, name := range names {
+		assert.Nil(t, VerifyNDJSON(strings.NewReader(store.objects[name])))
+	}
+}
+
+func TestStorageSinkFlushInterval(t *testing.T) {
+	store := &memoryStorage{objects: map[string]string{}}
+	logger := logtest.New()
+	sink := NewStorageSink(store, StorageSinkConfig{FlushInterval: 10 * time.Millisecond, Logger: logger})
+	defer sink.Close()
+	started := fmt.Sprintf("%020d", sink.(*storageSink).started)
+
+	store.setErr(errors.New("unavailable"))
+	_, err := NewAuditor([]Sink{sink}).Record(adminContext(), Action{Name: "template.update"})
+	assert.Nil(t, err)
+
+	// a failed interval flush is logged and retried
+	assert.Eventually(t, func() bool { return len(logger.Entries()) > 0 }, time.Second, 5*time.Millisecond)
+	logger.AssertLogged(t, logrus.ErrorLevel, "audit: open audit/"+started+"-00000000000000000001-00000000000000000001.ndjson: unavailable, retried with the next flush", nil)
+
+	store.setErr(nil)
+	assert.Eventually(t, func() bool { return len(store.names()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"audit/" + started + "-00000000000000000001-00000000000000000001.ndjson"}, store.names())
+}
+
+func TestPublisherSink(t *testing.T) {
+	publisher := &memoryPublisher{}
+	record, err := NewAuditor([]Sink{NewPublisherSink(publisher, "audit.admin")}).Record(adminContext(), Action{Name: "template.update"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "audit.admin", publisher.topic)
+	assert.Equal(t, []interface{}{record}, publisher.messages)
+}
@@ -153,7 +153,10 @@ func AuthenticateJWTMiddleware(jwtSecret string) beego.LinkNamespace {
 			errMessage, _ := json.Marshal(map[string]interface{}{"message": "you are not authorized to access this resource"})
 			ctx.ResponseWriter.WriteHeader(http.StatusForbidden)
 			ctx.ResponseWriter.Write(errMessage)
+			return
 		}
+		// expose claims to handlers, e.g. to record the actor of audit trails
+		ctx.Request = ctx.Request.WithContext(WithClaims(ctx.Request.Context(), &jwtAuthorization.JwtClaims))
 		return
 	})
 }
@@ -1,6 +1,7 @@
 package jwtauthz
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -139,3 +140,18 @@ func TestJwtAuthorization(t *testing.T) {
 		})
 	})
 }
+
+func TestClaimsContext(t *testing.T) {
+	Convey("ClaimsFromContext()", t, func() {
+		Convey("When context has no claims", func() {
+			_, ok := ClaimsFromContext(context.Background())
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("When claims are added with WithClaims", func() {
+			claims, ok := ClaimsFromContext(WithClaims(context.Background(), &JwtClaims{ID: 2, Email: "budiryan@hotmail.com"}))
+			So(ok, ShouldBeTrue)
+			So(claims.ID, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,21 @@
+package jwtauthz
+
+import "context"
+
+type claimsKeyType struct{}
+
+var claimsKey = claimsKeyType{}
+
+// WithClaims returns context carrying claims, AuthenticateJWTMiddleware sets it on the request context
+func WithClaims(ctx context.Context, claims *JwtClaims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns claims added by WithClaims
+func ClaimsFromContext(ctx context.Context) (*JwtClaims, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	claims, ok := ctx.Value(claimsKey).(*JwtClaims)
+	return claims, ok && claims != nil
+}
@@ -164,6 +164,11 @@ func (r *redigoImpl) Do(commandName string, args ...interface{}) (reply interfac
 	return
 }
 
+func (r *redigoImpl) ping() error {
+	_, err := r.Do(redisPing)
+	return err
+}
+
 func (r *redigoImpl) Set(key, value string, ttl time.Duration) error {
 	var err error
 
@@ -0,0 +1,800 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/go-redis/redis/v7"
+)
+
+// ReplicaOptions controls how ReplicatedCache routes read commands.
+type ReplicaOptions struct {
+	// SlaveOnly routes reads to replicas only. When false, the master is also part of the read pool.
+	SlaveOnly bool
+	// RouteByLatency routes reads to the replica with the lowest measured PING latency.
+	// When false, or when probing is disabled, replicas are picked round-robin.
+	RouteByLatency bool
+	// ProbeInterval is the interval between replica PING probes used for latency and health checking.
+	// Defaults to 1s, a negative value disables probing.
+	ProbeInterval time.Duration
+	// StickyWindow is how long reads stay on the master after a write made within a
+	// WithReadYourWrites context. Zero keeps them on the master for the lifetime of the context.
+	StickyWindow time.Duration
+}
+
+// ConfigReplicated is the redigo config for a master and its read replicas.
+type ConfigReplicated struct {
+	Master   *Config
+	Replicas []*Config
+	ReplicaOptions
+}
+
+// FailoverReplicatedOptions is the sentinel config for ReplicatedCache. Replicas are discovered from the sentinels.
+type FailoverReplicatedOptions struct {
+	FailoverOptions
+	ReplicaOptions
+	// RefreshInterval is the interval between replica discoveries, so promoted, added
+	// and removed replicas are picked up after a failover.
+	// Defaults to 30s, a negative value disables refreshing.
+	RefreshInterval time.Duration
+}
+
+var (
+	ErrNoReplicas = errors.New("no replicas found")
+)
+
+const (
+	defaultProbeInterval   = time.Second
+	defaultRefreshInterval = 30 * time.Second
+)
+
+type readYourWritesKeyType struct{}
+
+var readYourWritesKey = readYourWritesKeyType{}
+
+type readYourWrites struct {
+	lastWrite int64
+}
+
+// WithReadYourWrites returns a context in which reads issued through ReplicatedCache.WithContext
+// go to the master once a write has been made through the same context.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(readYourWritesKey).(*readYourWrites); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, readYourWritesKey, &readYourWrites{})
+}
+
+type pinger interface {
+	ping() error
+}
+
+type replicaNode struct {
+	Cache
+	latency int64
+	down    int32
+}
+
+func (n *replicaNode) probe() {
+	p, ok := n.Cache.(pinger)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	if err := p.ping(); err != nil {
+		atomic.StoreInt32(&n.down, 1)
+		return
+	}
+	atomic.StoreInt32(&n.down, 0)
+
+	sample := int64(time.Since(start))
+	prev := atomic.LoadInt64(&n.latency)
+	if prev == 0 {
+		atomic.StoreInt64(&n.latency, sample)
+	} else {
+		atomic.StoreInt64(&n.latency, (prev+sample)/2)
+	}
+}
+
+func (n *replicaNode) healthy() bool {
+	return atomic.LoadInt32(&n.down) == 0
+}
+
+type replicaSet struct {
+	opt     ReplicaOptions
+	master  *replicaNode
+	mu      sync.RWMutex
+	nodes   []*replicaNode
+	next    uint32
+	closers []func() error
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// ReplicatedCache is a Cache that sends writes to the master and reads to replicas.
+type ReplicatedCache struct {
+	master Cache
+	set    *replicaSet
+	ctx    context.Context
+}
+
+var _ Cache = (*ReplicatedCache)(nil)
+
+// NewReplicated return ready to use ReplicatedCache instance.
+// Supported implementations are Redis with *ConfigReplicated and RedisSentinel with *FailoverReplicatedOptions.
+func NewReplicated(impl Implementation, cfg interface{}) (*ReplicatedCache, error) {
+	switch impl {
+	case Redis:
+		return newRedigoReplicated(cfg.(*ConfigReplicated))
+	case RedisSentinel:
+		return newRedisSentinelReplicated(cfg.(*FailoverReplicatedOptions))
+	}
+
+	return nil, errors.New("no replicated cache implementations found")
+}
+
+// NewReplicatedCache builds a ReplicatedCache from already initialized master and replica clients.
+// Replicas are probed for latency and health only when they were created by this package.
+func NewReplicatedCache(master Cache, replicas []Cache, opt ReplicaOptions) *ReplicatedCache {
+	nodes := make([]*replicaNode, 0, len(replicas))
+	for _, replica := range replicas {
+		nodes = append(nodes, &replicaNode{Cache: replica})
+	}
+	if opt.ProbeInterval == 0 {
+		opt.ProbeInterval = defaultProbeInterval
+	}
+
+	set := &replicaSet{
+		opt:    opt,
+		master: &replicaNode{Cache: master},
+		stop:   make(chan struct{}),
+	}
+	set.update(nodes)
+	if opt.ProbeInterval > 0 {
+		set.probeAll()
+		go set.probeLoop()
+	}
+
+	return &ReplicatedCache{
+		master: master,
+		set:    set,
+		ctx:    context.Background(),
+	}
+}
+
+func newRedigoReplicated(cfg *ConfigReplicated) (*ReplicatedCache, error) {
+	master, err := newRedigo(cfg.Master)
+	if err != nil {
+		master.Pool.Close()
+		return nil, err
+	}
+
+	closers := []func() error{master.Pool.Close}
+	replicas := make([]Cache, 0, len(cfg.Replicas))
+	for _, replicaCfg := range cfg.Replicas {
+		// an unreachable replica is kept and left to the health probe
+		replica, _ := newRedigo(replicaCfg)
+		replicas = append(replicas, replica)
+		closers = append(closers, replica.Pool.Close)
+	}
+
+	r := NewReplicatedCache(master, replicas, cfg.ReplicaOptions)
+	r.set.closers = closers
+	return r, nil
+}
+
+func newRedisSentinelReplicated(opt *FailoverReplicatedOptions) (*ReplicatedCache, error) {
+	master, err := newRedisSentinel(&opt.FailoverOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := discoverSlaveAddrs(&opt.FailoverOptions)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	if len(addrs) == 0 && opt.SlaveOnly {
+		master.Close()
+		return nil, ErrNoReplicas
+	}
+
+	refresher := &sentinelReplicas{opt: opt, nodes: make(map[string]*replicaNode, len(addrs))}
+	r := NewReplicatedCache(master, nil, opt.ReplicaOptions)
+	r.set.closers = []func() error{master.Close, refresher.close}
+	refresher.apply(r.set, addrs)
+
+	interval := opt.RefreshInterval
+	if interval == 0 {
+		interval = defaultRefreshInterval
+	}
+	if interval > 0 {
+		go refresher.loop(r.set, interval)
+	}
+	return r, nil
+}
+
+// sentinelReplicas keeps the replica clients of a sentinel ReplicatedCache in sync with the sentinels
+type sentinelReplicas struct {
+	opt   *FailoverReplicatedOptions
+	mu    sync.Mutex
+	nodes map[string]*replicaNode
+}
+
+func (s *sentinelReplicas) loop(set *replicaSet, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-set.stop:
+			return
+		case <-ticker.C:
+			// keep the current replicas when the sentinels can't be reached
+			if addrs, err := discoverSlaveAddrs(&s.opt.FailoverOptions); err == nil {
+				s.apply(set, addrs)
+			}
+		}
+	}
+}
+
+// apply opens clients for new replicas, closes the ones that are gone and swaps the read pool of set
+func (s *sentinelReplicas) apply(set *replicaSet, addrs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make(map[string]*replicaNode, len(addrs))
+	for _, addr := range addrs {
+		node, ok := s.nodes[addr]
+		if !ok {
+			node = &replicaNode{Cache: s.newClient(addr)}
+			if set.opt.ProbeInterval > 0 {
+				node.probe()
+			}
+		}
+		current[addr] = node
+	}
+
+	var removed []*replicaNode
+	for addr, node := range s.nodes {
+		if _, ok := current[addr]; !ok {
+			removed = append(removed, node)
+		}
+	}
+	s.nodes = current
+
+	sort.Strings(addrs)
+	nodes := make([]*replicaNode, 0, len(addrs))
+	for _, addr := range addrs {
+		nodes = append(nodes, current[addr])
+	}
+	set.update(nodes)
+
+	for _, node := range removed {
+		node.Cache.(*redisSentinelImpl).Close()
+	}
+}
+
+func (s *sentinelReplicas) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for _, node := range s.nodes {
+		if closeErr := node.Cache.(*redisSentinelImpl).Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	s.nodes = nil
+	return err
+}
+
+func (s *sentinelReplicas) newClient(addr string) *redisSentinelImpl {
+	opt := s.opt
+	return &redisSentinelImpl{client: goredis.NewClient(&goredis.Options{
+		Addr:               addr,
+		OnConnect:          opt.OnConnect,
+		Password:           opt.Password,
+		DB:                 opt.DB,
+		MaxRetries:         opt.MaxRetries,
+		MinRetryBackoff:    opt.MinRetryBackoff,
+		MaxRetryBackoff:    opt.MaxRetryBackoff,
+		DialTimeout:        opt.DialTimeout,
+		ReadTimeout:        opt.ReadTimeout,
+		WriteTimeout:       opt.WriteTimeout,
+		PoolSize:           opt.PoolSize,
+		MinIdleConns:       opt.MinIdleConns,
+		MaxConnAge:         opt.MaxConnAge,
+		PoolTimeout:        opt.PoolTimeout,
+		IdleTimeout:        opt.IdleTimeout,
+		IdleCheckFrequency: opt.IdleCheckFrequency,
+		TLSConfig:          opt.TLSConfig,
+	})}
+}
+
+// discoverSlaveAddrs asks the sentinels for the healthy slaves of the master
+func discoverSlaveAddrs(opt *FailoverOptions) ([]string, error) {
+	var lastErr error = ErrFailInitialize
+
+	for _, sentinelAddr := range opt.SentinelAddrs {
+		sentinel := goredis.NewSentinelClient(&goredis.Options{
+			Addr:         sentinelAddr,
+			Password:     opt.SentinelPassword,
+			DialTimeout:  opt.DialTimeout,
+			ReadTimeout:  opt.ReadTimeout,
+			WriteTimeout: opt.WriteTimeout,
+			TLSConfig:    opt.TLSConfig,
+		})
+		slaves, err := sentinel.Slaves(opt.MasterName).Result()
+		sentinel.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return parseSlaveAddrs(slaves), nil
+	}
+
+	return nil, lastErr
+}
+
+func parseSlaveAddrs(slaves []interface{}) []string {
+	addrs := make([]string, 0, len(slaves))
+
+	for _, slave := range slaves {
+		var ip, port string
+		down := false
+
+		fields, _ := slave.([]interface{})
+		for i := 0; i+1 < len(fields); i += 2 {
+			key, _ := fields[i].(string)
+			value, _ := fields[i+1].(string)
+			switch key {
+			case "ip":
+				ip = value
+			case "port":
+				port = value
+			case "flags":
+				for _, flag := range strings.Split(value, ",") {
+					switch flag {
+					case "s_down", "o_down", "disconnected":
+						down = true
+					}
+				}
+			}
+		}
+
+		if ip != "" && port != "" && !down {
+			addrs = append(addrs, net.JoinHostPort(ip, port))
+		}
+	}
+
+	return addrs
+}
+
+// update replaces the read pool with replicas, plus the master unless reads are slave only
+func (s *replicaSet) update(replicas []*replicaNode) {
+	nodes := replicas
+	if !s.opt.SlaveOnly || len(nodes) == 0 {
+		nodes = append(nodes[:len(nodes):len(nodes)], s.master)
+	}
+
+	s.mu.Lock()
+	s.nodes = nodes
+	s.mu.Unlock()
+}
+
+func (s *replicaSet) snapshot() []*replicaNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nodes
+}
+
+func (s *replicaSet) probeAll() {
+	for _, node := range s.snapshot() {
+		node.probe()
+	}
+}
+
+func (s *replicaSet) probeLoop() {
+	ticker := time.NewTicker(s.opt.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.probeAll()
+		}
+	}
+}
+
+func (s *replicaSet) pick() *replicaNode {
+	nodes := s.snapshot()
+	// latencies are only measured by the probe, without it every replica looks equally fast
+	if s.opt.RouteByLatency && s.opt.ProbeInterval > 0 {
+		return pickByLatency(nodes)
+	}
+	return s.pickRoundRobin(nodes)
+}
+
+func (s *replicaSet) pickRoundRobin(nodes []*replicaNode) *replicaNode {
+	n := uint32(len(nodes))
+	start := atomic.AddUint32(&s.next, 1)
+
+	for i := uint32(0); i < n; i++ {
+		node := nodes[(start+i)%n]
+		if node.healthy() {
+			return node
+		}
+	}
+	return nil
+}
+
+func pickByLatency(nodes []*replicaNode) *replicaNode {
+	var (
+		picked *replicaNode
+		best   int64 = math.MaxInt64
+	)
+
+	for _, node := range nodes {
+		if !node.healthy() {
+			continue
+		}
+		if latency := atomic.LoadInt64(&node.latency); latency < best {
+			picked, best = node, latency
+		}
+	}
+	return picked
+}
+
+// WithContext returns a shallow copy of r that honours read-your-writes stickiness of ctx.
+func (r *ReplicatedCache) WithContext(ctx context.Context) *ReplicatedCache {
+	if ctx == nil {
+		panic("nil context")
+	}
+	clone := *r
+	clone.ctx = ctx
+	return &clone
+}
+
+// Context returns the context used for read-your-writes stickiness.
+func (r *ReplicatedCache) Context() context.Context {
+	return r.ctx
+}
+
+// Master returns the client used for write commands.
+func (r *ReplicatedCache) Master() Cache {
+	return r.master
+}
+
+// Close stops replica probing and closes the connections created by NewReplicated.
+func (r *ReplicatedCache) Close() error {
+	var err error
+
+	r.set.once.Do(func() {
+		close(r.set.stop)
+		for _, closer := range r.set.closers {
+			if closeErr := closer(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
+
+func (r *ReplicatedCache) reader() Cache {
+	if state, ok := r.ctx.Value(readYourWritesKey).(*readYourWrites); ok {
+		if lastWrite := atomic.LoadInt64(&state.lastWrite); lastWrite != 0 {
+			if r.set.opt.StickyWindow <= 0 || time.Since(time.Unix(0, lastWrite)) < r.set.opt.StickyWindow {
+				return r.master
+			}
+		}
+	}
+
+	if node := r.set.pick(); node != nil {
+		return node.Cache
+	}
+	return r.master
+}
+
+func (r *ReplicatedCache) writer() Cache {
+	if state, ok := r.ctx.Value(readYourWritesKey).(*readYourWrites); ok {
+		atomic.StoreInt64(&state.lastWrite, time.Now().UnixNano())
+	}
+	return r.master
+}
+
+// GetConn returns a master connection, since raw commands can't be classified as reads or writes.
+func (r *ReplicatedCache) GetConn() Conn {
+	return r.writer().GetConn()
+}
+
+func (r *ReplicatedCache) ErrorOnCacheMiss() error {
+	return r.master.ErrorOnCacheMiss()
+}
+
+func (r *ReplicatedCache) ErrorOnHashCacheMiss() error {
+	return r.master.ErrorOnHashCacheMiss()
+}
+
+func (r *ReplicatedCache) Set(key, value string, ttl time.Duration) error {
+	return r.writer().Set(key, value, ttl)
+}
+
+func (r *ReplicatedCache) Get(key string) (string, error) {
+	return r.reader().Get(key)
+}
+
+func (r *ReplicatedCache) Del(key ...string) error {
+	return r.writer().Del(key...)
+}
+
+func (r *ReplicatedCache) HSet(key, field, value string, ttl time.Duration) error {
+	return r.writer().HSet(key, field, value, ttl)
+}
+
+func (r *ReplicatedCache) HSetNX(key, field, value string, ttl time.Duration) error {
+	return r.writer().HSetNX(key, field, value, ttl)
+}
+
+func (r *ReplicatedCache) HMSet(key string, fieldsMap map[string]string, ttl time.Duration) error {
+	return r.writer().HMSet(key, fieldsMap, ttl)
+}
+
+func (r *ReplicatedCache) HGet(key, field string) (string, error) {
+	return r.reader().HGet(key, field)
+}
+
+func (r *ReplicatedCache) HMGet(key string, fields ...string) ([]string, error) {
+	return r.reader().HMGet(key, fields...)
+}
+
+func (r *ReplicatedCache) HDel(key string, fields ...string) (int64, error) {
+	return r.writer().HDel(key, fields...)
+}
+
+func (r *ReplicatedCache) HKeys(key string) ([]string, error) {
+	return r.reader().HKeys(key)
+}
+
+func (r *ReplicatedCache) HVals(key string) ([]string, error) {
+	return r.reader().HVals(key)
+}
+
+func (r *ReplicatedCache) HGetAll(key string) (map[string]string, error) {
+	return r.reader().HGetAll(key)
+}
+
+func (r *ReplicatedCache) HExists(key, field string) (bool, error) {
+	return r.reader().HExists(key, field)
+}
+
+func (r *ReplicatedCache) HIncrBy(key, field string, incrValue int64) (int64, error) {
+	return r.writer().HIncrBy(key, field, incrValue)
+}
+
+func (r *ReplicatedCache) IncrXX(key string, value int64) (int64, error) {
+	return r.writer().IncrXX(key, value)
+}
+
+func (r *ReplicatedCache) DecrWithLimit(key string, value, lowerBound int64) (int64, error) {
+	return r.writer().DecrWithLimit(key, value, lowerBound)
+}
+
+func (r *ReplicatedCache) HGetSet(key, field, value, prevValue string, ttl time.Duration) error {
+	return r.writer().HGetSet(key, field, value, prevValue, ttl)
+}
+
+func (r *ReplicatedCache) ZAddToFixed(key, member string, score, maxSize int) (int64, error) {
+	return r.writer().ZAddToFixed(key, member, score, maxSize)
+}
+
+func (r *ReplicatedCache) MSet(values map[string]string) error {
+	return r.writer().MSet(values)
+}
+
+func (r *ReplicatedCache) MSetEx(values map[string]string, ttl time.Duration) error {
+	return r.writer().MSetEx(values, ttl)
+}
+
+func (r *ReplicatedCache) MGet(keys []string) ([]string, error) {
+	return r.reader().MGet(keys)
+}
+
+func (r *ReplicatedCache) SetNX(key, value string, ttl time.Duration) error {
+	return r.writer().SetNX(key, value, ttl)
+}
+
+func (r *ReplicatedCache) ScanKeys(pattern string) ([]string, error) {
+	return r.reader().ScanKeys(pattern)
+}
+
+func (r *ReplicatedCache) IncrBy(key string, incr int64) (int64, error) {
+	return r.writer().IncrBy(key, incr)
+}
+
+func (r *ReplicatedCache) ZAdd(key, member string, score int) error {
+	return r.writer().ZAdd(key, member, score)
+}
+
+func (r *ReplicatedCache) ZAddXX(key, member string, score int) error {
+	return r.writer().ZAddXX(key, member, score)
+}
+
+func (r *ReplicatedCache) ZAddNX(key, member string, score int64) (int64, error) {
+	return r.writer().ZAddNX(key, member, score)
+}
+
+func (r *ReplicatedCache) ZAddINCR(key, member string, score int) error {
+	return r.writer().ZAddINCR(key, member, score)
+}
+
+func (r *ReplicatedCache) ZCard(key string) (int64, error) {
+	return r.reader().ZCard(key)
+}
+
+func (r *ReplicatedCache) ZRange(key string, start, stop int) ([]string, error) {
+	return r.reader().ZRange(key, start, stop)
+}
+
+func (r *ReplicatedCache) ZRevRange(key string, start, stop int) ([]string, error) {
+	return r.reader().ZRevRange(key, start, stop)
+}
+
+func (r *ReplicatedCache) ZRangeByScore(key string, min, max, offset, count int) ([]string, error) {
+	return r.reader().ZRangeByScore(key, min, max, offset, count)
+}
+
+func (r *ReplicatedCache) ZRevRangeByScore(key string, max, min, offset, count int) ([]string, error) {
+	return r.reader().ZRevRangeByScore(key, max, min, offset, count)
+}
+
+func (r *ReplicatedCache) ZRank(key, member string) (int64, error) {
+	return r.reader().ZRank(key, member)
+}
+
+func (r *ReplicatedCache) ZRevRank(key, member string) (int64, error) {
+	return r.reader().ZRevRank(key, member)
+}
+
+func (r *ReplicatedCache) ZScore(key, member string) (int64, error) {
+	return r.reader().ZScore(key, member)
+}
+
+func (r *ReplicatedCache) ZCount(key string, min, max int) (int64, error) {
+	return r.reader().ZCount(key, min, max)
+}
+
+func (r *ReplicatedCache) ZRemRangeByScore(key string, start, stop int) (int64, error) {
+	return r.writer().ZRemRangeByScore(key, start, stop)
+}
+
+func (r *ReplicatedCache) SAdd(key, member string) (int64, error) {
+	return r.writer().SAdd(key, member)
+}
+
+func (r *ReplicatedCache) SCard(key string) (int64, error) {
+	return r.reader().SCard(key)
+}
+
+func (r *ReplicatedCache) SDiff(keys ...string) ([]string, error) {
+	return r.reader().SDiff(keys...)
+}
+
+func (r *ReplicatedCache) SDiffStore(keys ...string) (int64, error) {
+	return r.writer().SDiffStore(keys...)
+}
+
+func (r *ReplicatedCache) SInter(keys ...string) ([]string, error) {
+	return r.reader().SInter(keys...)
+}
+
+func (r *ReplicatedCache) SInterStore(keys ...string) (int64, error) {
+	return r.writer().SInterStore(keys...)
+}
+
+func (r *ReplicatedCache) SIsMember(keys, member string) (int64, error) {
+	return r.reader().SIsMember(keys, member)
+}
+
+func (r *ReplicatedCache) SMembers(key string) ([]string, error) {
+	return r.reader().SMembers(key)
+}
+
+func (r *ReplicatedCache) SMove(value, source, destination string) (int64, error) {
+	return r.writer().SMove(value, source, destination)
+}
+
+func (r *ReplicatedCache) SPop(key string, count int) ([]string, error) {
+	return r.writer().SPop(key, count)
+}
+
+func (r *ReplicatedCache) SRandMember(key string, count int) ([]string, error) {
+	return r.reader().SRandMember(key, count)
+}
+
+func (r *ReplicatedCache) SRem(key string, member string) (int64, error) {
+	return r.writer().SRem(key, member)
+}
+
+func (r *ReplicatedCache) SUnion(keys ...string) ([]string, error) {
+	return r.reader().SUnion(keys...)
+}
+
+func (r *ReplicatedCache) SUnionStore(keys ...string) (int64, error) {
+	return r.writer().SUnionStore(keys...)
+}
+
+func (r *ReplicatedCache) ZRem(key string, members ...string) (int64, error) {
+	return r.writer().ZRem(key, members...)
+}
+
+func (r *ReplicatedCache) ZAddXXIncrBy(key, member string, incrValue int64) (int64, error) {
+	return r.writer().ZAddXXIncrBy(key, member, incrValue)
+}
+
+func (r *ReplicatedCache) Expire(key string, ttl time.Duration) (int64, error) {
+	return r.writer().Expire(key, ttl)
+}
+
+func (r *ReplicatedCache) Exists(key string) (bool, error) {
+	return r.reader().Exists(key)
+}
+
+func (r *ReplicatedCache) ZRevRangeWithScore(key string, start, stop int64) (interface{}, error) {
+	return r.reader().ZRevRangeWithScore(key, start, stop)
+}
+
+func (r *ReplicatedCache) GeoAdd(key string, geos ...*GeoPoint) (int64, error) {
+	return r.writer().GeoAdd(key, geos...)
+}
+
+func (r *ReplicatedCache) GeoHash(key string, members ...string) ([]string, error) {
+	return r.reader().GeoHash(key, members...)
+}
+
+func (r *ReplicatedCache) GeoRadius(key string, long, lat float64, q *GeoRadiusQuery) ([]*GeoLoc, error) {
+	return r.reader().GeoRadius(key, long, lat, q)
+}
+
+func (r *ReplicatedCache) TTL(key string) (int64, error) {
+	return r.reader().TTL(key)
+}
+
+func (r *ReplicatedCache) LLen(key string) (int64, error) {
+	return r.reader().LLen(key)
+}
+
+func (r *ReplicatedCache) LPop(key string, count int) ([]string, error) {
+	return r.writer().LPop(key, count)
+}
+
+func (r *ReplicatedCache) LPush(key string, values []string) (int64, error) {
+	return r.writer().LPush(key, values)
+}
+
+func (r *ReplicatedCache) LPushX(key string, values []string) (int64, error) {
+	return r.writer().LPushX(key, values)
+}
+
+func (r *ReplicatedCache) RPop(key string, count int) ([]string, error) {
+	return r.writer().RPop(key, count)
+}
+
+func (r *ReplicatedCache) RPush(key string, values []string) (int64, error) {
+	return r.writer().RPush(key, values)
+}
+
+func (r *ReplicatedCache) RPushX(key string, values []string) (int64, error) {
+	return r.writer().RPushX(key, values)
+}
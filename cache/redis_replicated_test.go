@@ -0,0 +1,127 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/muhammad-fakhri/go-libs/cache"
+	"github.com/muhammad-fakhri/go-libs/cache/mock_cache"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestReplicatedCache(t *testing.T) {
+	convey.Convey("test replicated cache", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		master := mock_cache.NewMockCache(ctrl)
+		replica1 := mock_cache.NewMockCache(ctrl)
+		replica2 := mock_cache.NewMockCache(ctrl)
+
+		convey.Convey("writes go to master, reads go round-robin to replicas", func() {
+			c := cache.NewReplicatedCache(master, []cache.Cache{replica1, replica2}, cache.ReplicaOptions{
+				SlaveOnly:     true,
+				ProbeInterval: -1,
+			})
+			defer c.Close()
+
+			master.EXPECT().Set("key", "value", time.Second).Return(nil).Times(1)
+			replica1.EXPECT().Get("key").Return("value", nil).Times(1)
+			replica2.EXPECT().Get("key").Return("value", nil).Times(1)
+
+			convey.So(c.Set("key", "value", time.Second), convey.ShouldBeNil)
+			for i := 0; i < 2; i++ {
+				v, err := c.Get("key")
+				convey.So(v, convey.ShouldEqual, "value")
+				convey.So(err, convey.ShouldBeNil)
+			}
+		})
+
+		convey.Convey("master serves reads when it is not slave only", func() {
+			c := cache.NewReplicatedCache(master, []cache.Cache{replica1}, cache.ReplicaOptions{
+				ProbeInterval: -1,
+			})
+			defer c.Close()
+
+			master.EXPECT().HGet("key", "field").Return("value", nil).Times(1)
+			replica1.EXPECT().HGet("key", "field").Return("value", nil).Times(1)
+
+			for i := 0; i < 2; i++ {
+				_, err := c.HGet("key", "field")
+				convey.So(err, convey.ShouldBeNil)
+			}
+		})
+
+		convey.Convey("reads stick to master after a write within read-your-writes context", func() {
+			c := cache.NewReplicatedCache(master, []cache.Cache{replica1}, cache.ReplicaOptions{
+				SlaveOnly:     true,
+				ProbeInterval: -1,
+			})
+			defer c.Close()
+
+			ctx := cache.WithReadYourWrites(context.Background())
+			sticky := c.WithContext(ctx)
+
+			replica1.EXPECT().Get("key").Return("", cache.ErrNil).Times(1)
+			master.EXPECT().Set("key", "value", time.Duration(0)).Return(nil).Times(1)
+			master.EXPECT().Get("key").Return("value", nil).Times(1)
+			replica1.EXPECT().Get("other").Return("value", nil).Times(1)
+
+			_, err := sticky.Get("key")
+			convey.So(err, convey.ShouldEqual, cache.ErrNil)
+			convey.So(sticky.WithContext(ctx).Set("key", "value", 0), convey.ShouldBeNil)
+			v, err := sticky.Get("key")
+			convey.So(v, convey.ShouldEqual, "value")
+			convey.So(err, convey.ShouldBeNil)
+
+			// other contexts keep reading from replicas
+			_, err = c.Get("other")
+			convey.So(err, convey.ShouldBeNil)
+		})
+
+		convey.Convey("stickiness expires after sticky window", func() {
+			c := cache.NewReplicatedCache(master, []cache.Cache{replica1}, cache.ReplicaOptions{
+				SlaveOnly:     true,
+				ProbeInterval: -1,
+				StickyWindow:  time.Millisecond,
+			})
+			defer c.Close()
+
+			sticky := c.WithContext(cache.WithReadYourWrites(context.Background()))
+
+			master.EXPECT().Del("key").Return(nil).Times(1)
+			replica1.EXPECT().Exists("key").Return(false, nil).Times(1)
+
+			convey.So(sticky.Del("key"), convey.ShouldBeNil)
+			time.Sleep(2 * time.Millisecond)
+			exists, err := sticky.Exists("key")
+			convey.So(exists, convey.ShouldBeFalse)
+			convey.So(err, convey.ShouldBeNil)
+		})
+
+		convey.Convey("route by latency falls back to round-robin without probing", func() {
+			c := cache.NewReplicatedCache(master, []cache.Cache{replica1, replica2}, cache.ReplicaOptions{
+				SlaveOnly:      true,
+				RouteByLatency: true,
+				ProbeInterval:  -1,
+			})
+			defer c.Close()
+
+			replica1.EXPECT().Get("key").Return("value", nil).Times(1)
+			replica2.EXPECT().Get("key").Return("value", nil).Times(1)
+
+			for i := 0; i < 2; i++ {
+				_, err := c.Get("key")
+				convey.So(err, convey.ShouldBeNil)
+			}
+		})
+
+		convey.Convey("test new replicated", func() {
+			c, err := cache.NewReplicated(123, &cache.ConfigReplicated{})
+			convey.So(c, convey.ShouldBeNil)
+			convey.So(err, convey.ShouldNotBeNil)
+		})
+	})
+}
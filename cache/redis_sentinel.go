@@ -78,6 +78,10 @@ func newRedisSentinel(opt *FailoverOptions) (*redisSentinelImpl, error) {
 	return &sentinel, err
 }
 
+func (r *redisSentinelImpl) ping() error {
+	return r.client.Ping().Err()
+}
+
 func (r *redisSentinelImpl) Set(key, value string, ttl time.Duration) error {
 	var err error
 
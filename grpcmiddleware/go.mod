@@ -7,8 +7,11 @@ require (
 	github.com/google/uuid v1.3.0
 	github.com/grpc-ecosystem/go-grpc-middleware v1.2.0
 	github.com/kazegusuri/grpc-panic-handler v0.0.0-20160502122501-093ec776affc
-	github.com/muhammad-fakhri/go-libs/log v1.0.0
+	github.com/muhammad-fakhri/go-libs/log v1.1.0
 	github.com/smartystreets/goconvey v1.6.4
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 	google.golang.org/grpc v1.39.0
 	google.golang.org/protobuf v1.25.0
 )
@@ -17,13 +17,24 @@ const (
 	typeIngressGRPC = "ingress_grpc"
 )
 
-func PayloadUnaryServerLogInterceptor(logger log.SLogger) grpc.UnaryServerInterceptor {
+// PayloadUnaryServerLogInterceptor logs request and response payload, masked by optionalRedactor or log.DefaultRedactor().
+// Every call runs in a server span continuing the trace of the incoming traceparent metadata.
+func PayloadUnaryServerLogInterceptor(logger log.SLogger, optionalRedactor ...*log.Redactor) grpc.UnaryServerInterceptor {
+	redactor := log.DefaultRedactor()
+	if len(optionalRedactor) > 0 && optionalRedactor[0] != nil {
+		redactor = optionalRedactor[0]
+	}
+
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		startTime := time.Now()
 		rr := &log.RequestResponse{}
 
+		ctx, span := startServerSpan(ctx, info.FullMethod)
+		defer span.End()
+
 		rctx := getRequestContext(ctx)
 		resp, err := handler(rctx, req)
+		setSpanStatus(span, err)
 		if err == nil {
 			rr.Status = statusOK
 		} else {
@@ -39,6 +50,7 @@ func PayloadUnaryServerLogInterceptor(logger log.SLogger) grpc.UnaryServerInterc
 		rr.URLPath = info.FullMethod
 		rr.DurationMs = time.Since(startTime).Milliseconds()
 		rr.RequestTimestamp = startTime
+		redactor.RedactRequestResponse(rr)
 
 		if err != nil {
 			logger.LogRequestResponse(rctx, rr, err)
@@ -12,7 +12,12 @@ import (
 	pb "github.com/muhammad-fakhri/go-libs/grpcmiddleware/example/proto/example"
 	"github.com/muhammad-fakhri/go-libs/grpcmiddleware/example/server"
 	"github.com/muhammad-fakhri/go-libs/log"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -64,3 +69,77 @@ func TestMiddleware(t *testing.T) {
 		So(resp.Status, ShouldEqual, 1)
 	})
 }
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func TestPayloadLogRedaction(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/example.Service/Login"}
+	req := &loginRequest{Username: "john", Password: "p4ss"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return map[string]string{"access_token": "abc"}, nil
+	}
+
+	Convey("Default Redactor", t, func() {
+		logger, hook := log.NewSLoggerWithTestHook("grpc")
+		interceptor := grpcmiddleware.PayloadUnaryServerLogInterceptor(logger)
+
+		resp, err := interceptor(context.Background(), req, info, handler)
+		So(err, ShouldBeNil)
+		So(resp, ShouldResemble, map[string]string{"access_token": "abc"})
+		So(req.Password, ShouldEqual, "p4ss")
+
+		data := hook.LastEntry().Data
+		So(data[log.FieldReqBody], ShouldResemble, map[string]interface{}{"username": "john", "password": "[REDACTED]"})
+		So(data[log.FieldResponseBody], ShouldResemble, map[string]interface{}{"access_token": "[REDACTED]"})
+	})
+
+	Convey("Custom Redactor", t, func() {
+		logger, hook := log.NewSLoggerWithTestHook("grpc")
+		interceptor := grpcmiddleware.PayloadUnaryServerLogInterceptor(logger, log.NewRedactor(log.RedactFields(log.MaskPartial, "username")))
+
+		_, err := interceptor(context.Background(), req, info, handler)
+		So(err, ShouldBeNil)
+
+		data := hook.LastEntry().Data
+		So(data[log.FieldReqBody], ShouldResemble, map[string]interface{}{"username": "****", "password": "p4ss"})
+	})
+}
+
+func TestPayloadLogTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/example.ExampleService/Ping"}
+
+	Convey("Continue Incoming Trace", t, func() {
+		logger, hook := log.NewSLoggerWithTestHook("grpc")
+		interceptor := grpcmiddleware.PayloadUnaryServerLogInterceptor(logger)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		))
+
+		var outgoing metadata.MD
+		_, err := interceptor(ctx, struct{}{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			outgoing, _ = metadata.FromOutgoingContext(grpcmiddleware.CreateRequestMetadata(ctx))
+			return nil, nil
+		})
+		So(err, ShouldBeNil)
+
+		data := hook.LastEntry().Data
+		So(data[log.FieldTraceID], ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+
+		spans := recorder.Ended()
+		So(len(spans), ShouldBeGreaterThan, 0)
+		span := spans[len(spans)-1]
+		So(span.Name(), ShouldEqual, "example.ExampleService/Ping")
+		So(span.Parent().SpanID().String(), ShouldEqual, "00f067aa0ba902b7")
+		So(data[log.FieldSpanID], ShouldEqual, span.SpanContext().SpanID().String())
+
+		// downstream calls continue the same trace
+		So(outgoing.Get("traceparent")[0], ShouldStartWith, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID().String())
+	})
+}
@@ -0,0 +1,101 @@
+package grpcmiddleware
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const tracerName = "github.com/muhammad-fakhri/go-libs/grpcmiddleware"
+
+// propagator reads and writes W3C traceparent, tracestate and baggage metadata
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// metadataCarrier adapts metadata.MD to propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// TracingUnaryClientInterceptor starts a client span for every call and sends its traceparent in the outgoing metadata
+func TracingUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := otel.Tracer(tracerName).Start(ctx, strings.TrimPrefix(method, "/"),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(rpcAttributes(method)...),
+		)
+		defer span.End()
+
+		err := invoker(injectMetadata(ctx), method, req, reply, cc, opts...)
+		setSpanStatus(span, err)
+		return err
+	}
+}
+
+// startServerSpan continues the trace of the incoming traceparent metadata, or starts a new one
+func startServerSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = propagator.Extract(ctx, metadataCarrier(md))
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(rpcAttributes(fullMethod)...),
+	)
+}
+
+// injectMetadata adds traceparent of the span in ctx to the outgoing metadata
+func injectMetadata(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+
+	propagator.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.RPCSystemKey.String("grpc")}
+
+	parts := strings.SplitN(strings.TrimPrefix(fullMethod, "/"), "/", 2)
+	if len(parts) == 2 {
+		attrs = append(attrs, semconv.RPCServiceKey.String(parts[0]), semconv.RPCMethodKey.String(parts[1]))
+	}
+	return attrs
+}
+
+func setSpanStatus(span trace.Span, err error) {
+	s, _ := status.FromError(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int64(int64(s.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, s.Message())
+	}
+}
@@ -7,14 +7,17 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
-// client helper, to add common fields from context "value" (see go-libs/log) to metadata
+// client helper, to add common fields from context "value" (see go-libs/log) and traceparent of the span in context to metadata
 func CreateRequestMetadata(ctx context.Context) context.Context {
-	dataMap := ctx.Value(log.ContextDataMapKey)
-	if dataMap == nil {
-		return ctx
+	md := metadata.MD{}
+	if dataMap, ok := ctx.Value(log.ContextDataMapKey).(map[string]string); ok {
+		md = metadata.New(dataMap)
 	}
 
-	md := metadata.New(dataMap.(map[string]string))
+	propagator.Inject(ctx, metadataCarrier(md))
+	if md.Len() == 0 {
+		return ctx
+	}
 
 	return metadata.NewOutgoingContext(ctx, md)
 }
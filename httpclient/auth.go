@@ -0,0 +1,385 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	headerAuthorization = "Authorization"
+
+	defaultHMACKeyIDHeader     = "X-Key-Id"
+	defaultHMACTimestampHeader = "X-Timestamp"
+	defaultHMACNonceHeader     = "X-Nonce"
+	defaultHMACSignatureHeader = "X-Signature"
+
+	defaultTokenRefreshBefore = 30 * time.Second
+	defaultTokenTTL           = 5 * time.Minute
+	defaultTokenTimeout       = 10 * time.Second
+)
+
+// AuthProvider adds credentials to an outgoing request. It is called on a copy of the request for every attempt,
+// right before it is sent, the request logged by the doer never carries the credentials.
+type AuthProvider interface {
+	Authenticate(request *http.Request) error
+}
+
+// AuthFunc is an AuthProvider function, e.g. to read a key from a secret store
+type AuthFunc func(request *http.Request) error
+
+func (f AuthFunc) Authenticate(request *http.Request) error {
+	return f(request)
+}
+
+// authenticate returns copy of request with the credentials of provider
+func authenticate(provider AuthProvider, request *http.Request) (*http.Request, error) {
+	if provider == nil {
+		return request, nil
+	}
+
+	authenticated := request.Clone(request.Context())
+	if err := provider.Authenticate(authenticated); err != nil {
+		return nil, fmt.Errorf("httpclient: authenticate: %w", err)
+	}
+	return authenticated, nil
+}
+
+// NewAPIKeyAuth returns provider setting the static key to header, e.g. "X-Api-Key"
+func NewAPIKeyAuth(header, key string) AuthProvider {
+	return AuthFunc(func(request *http.Request) error {
+		request.Header.Set(header, key)
+		return nil
+	})
+}
+
+func NewBasicAuth(username, password string) AuthProvider {
+	return AuthFunc(func(request *http.Request) error {
+		request.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// HMACConfig signs requests with HMAC-SHA256 of Secret over the string
+//
+//	METHOD + "\n" + PATH?QUERY + "\n" + TIMESTAMP + "\n" + NONCE + "\n" + hex(sha256(BODY))
+//
+// TIMESTAMP is unix seconds, NONCE is 16 random bytes in hex, the signature is sent in hex.
+type HMACConfig struct {
+	KeyID           string // sent in KeyIDHeader when set, tells the partner which secret signed the request
+	Secret          []byte // required
+	KeyIDHeader     string // default value: X-Key-Id
+	TimestampHeader string // default value: X-Timestamp
+	NonceHeader     string // default value: X-Nonce
+	SignatureHeader string // default value: X-Signature
+}
+
+type hmacAuth struct {
+	config HMACConfig
+	now    func() time.Time
+	nonce  func() (string, error)
+}
+
+func NewHMACAuth(config HMACConfig) (AuthProvider, error) {
+	if len(config.Secret) == 0 {
+		return nil, errors.New("httpclient: hmac auth needs secret")
+	}
+
+	if config.KeyIDHeader == "" {
+		config.KeyIDHeader = defaultHMACKeyIDHeader
+	}
+	if config.TimestampHeader == "" {
+		config.TimestampHeader = defaultHMACTimestampHeader
+	}
+	if config.NonceHeader == "" {
+		config.NonceHeader = defaultHMACNonceHeader
+	}
+	if config.SignatureHeader == "" {
+		config.SignatureHeader = defaultHMACSignatureHeader
+	}
+
+	return &hmacAuth{config: config, now: time.Now, nonce: randomNonce}, nil
+}
+
+func (a *hmacAuth) Authenticate(request *http.Request) error {
+	body, err := readBody(request)
+	if err != nil {
+		return err
+	}
+	nonce, err := a.nonce()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(a.now().Unix(), 10)
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, a.config.Secret)
+	io.WriteString(mac, strings.Join([]string{
+		request.Method,
+		request.URL.RequestURI(),
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n"))
+
+	if a.config.KeyID != "" {
+		request.Header.Set(a.config.KeyIDHeader, a.config.KeyID)
+	}
+	request.Header.Set(a.config.TimestampHeader, timestamp)
+	request.Header.Set(a.config.NonceHeader, nonce)
+	request.Header.Set(a.config.SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+func randomNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// readBody returns the body of request, through GetBody when set, otherwise the body is read and restored
+func readBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// tokenCache keeps a bearer token until refreshBefore its expiry, concurrent callers wait for a single refresh
+type tokenCache struct {
+	refreshBefore time.Duration
+	now           func() time.Time
+	fetch         func(ctx context.Context) (token string, expiresAt time.Time, err error)
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (c *tokenCache) Authenticate(request *http.Request) error {
+	token, err := c.get(request.Context())
+	if err != nil {
+		return err
+	}
+	request.Header.Set(headerAuthorization, "Bearer "+token)
+	return nil
+}
+
+func (c *tokenCache) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.now().Before(c.expiresAt.Add(-c.refreshBefore)) {
+		return c.token, nil
+	}
+
+	token, expiresAt, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// ClientCredentialsConfig gets OAuth2 access tokens with the client credentials grant,
+// a token is reused until RefreshBefore its expiry
+type ClientCredentialsConfig struct {
+	TokenURL      string        // required
+	ClientID      string        // required
+	ClientSecret  string        // sent with basic auth
+	Scopes        []string      // default value: none, the default scopes of the client
+	Params        url.Values    // extra parameters of the token request, e.g. audience
+	Client        *http.Client  // token requests are not logged, default value: http.Client with 10s timeout
+	RefreshBefore time.Duration // default value: 30s
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// NewClientCredentialsAuth returns provider setting the access token as bearer token,
+// a token without expires_in is reused for 5 minutes
+func NewClientCredentialsAuth(config ClientCredentialsConfig) (AuthProvider, error) {
+	if config.TokenURL == "" || config.ClientID == "" {
+		return nil, errors.New("httpclient: client credentials auth needs token url and client id")
+	}
+
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: defaultTokenTimeout}
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = defaultTokenRefreshBefore
+	}
+
+	cache := &tokenCache{refreshBefore: config.RefreshBefore, now: time.Now}
+	cache.fetch = func(ctx context.Context) (string, time.Time, error) {
+		return fetchClientCredentialsToken(ctx, config, cache.now())
+	}
+	return cache, nil
+}
+
+func fetchClientCredentialsToken(ctx context.Context, config ClientCredentialsConfig, now time.Time) (string, time.Time, error) {
+	form := url.Values{}
+	for key, values := range config.Params {
+		form[key] = values
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(config.Scopes) > 0 {
+		form.Set("scope", strings.Join(config.Scopes, " "))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+
+	response, err := config.Client.Do(request)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token request: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token request: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("token request: status %d", response.StatusCode)
+	}
+
+	token := tokenResponse{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, errors.New("token response: no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("token response: unsupported token_type %s", token.TokenType)
+	}
+
+	ttl := defaultTokenTTL
+	if token.ExpiresIn > 0 {
+		ttl = time.Duration(token.ExpiresIn) * time.Second
+	}
+	return token.AccessToken, now.Add(ttl), nil
+}
+
+// JWTBearerConfig signs its own short lived JWT as bearer token, with the claims
+// iss, sub, aud, iat, exp and jti besides Claims. A token is reused until RefreshBefore its expiry.
+type JWTBearerConfig struct {
+	Issuer   string
+	Subject  string
+	Audience string
+	// Key signs the token, []byte for HS256/384/512, *rsa.PrivateKey for RS*, *ecdsa.PrivateKey for ES*, required
+	Key           interface{}
+	Method        jwt.SigningMethod // default value: jwt.SigningMethodHS256
+	KeyID         string            // kid header when set
+	Claims        map[string]interface{}
+	TTL           time.Duration // default value: 5m
+	RefreshBefore time.Duration // default value: 30s, at most half of TTL
+}
+
+func NewJWTBearerAuth(config JWTBearerConfig) (AuthProvider, error) {
+	if config.Key == nil {
+		return nil, errors.New("httpclient: jwt bearer auth needs key")
+	}
+
+	if config.Method == nil {
+		config.Method = jwt.SigningMethodHS256
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultTokenTTL
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = defaultTokenRefreshBefore
+	}
+	if config.RefreshBefore > config.TTL/2 {
+		config.RefreshBefore = config.TTL / 2
+	}
+
+	cache := &tokenCache{refreshBefore: config.RefreshBefore, now: time.Now}
+	cache.fetch = func(ctx context.Context) (string, time.Time, error) {
+		return signJWT(config, cache.now())
+	}
+
+	// a wrong key type only fails when signing, fail here instead
+	if _, err := cache.get(context.Background()); err != nil {
+		return nil, fmt.Errorf("httpclient: jwt bearer auth: %w", err)
+	}
+	return cache, nil
+}
+
+func signJWT(config JWTBearerConfig, now time.Time) (string, time.Time, error) {
+	nonce, err := randomNonce()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(config.TTL)
+
+	claims := jwt.MapClaims{}
+	for key, value := range config.Claims {
+		claims[key] = value
+	}
+	if config.Issuer != "" {
+		claims["iss"] = config.Issuer
+	}
+	if config.Subject != "" {
+		claims["sub"] = config.Subject
+	}
+	if config.Audience != "" {
+		claims["aud"] = config.Audience
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	claims["jti"] = nonce
+
+	token := jwt.NewWithClaims(config.Method, claims)
+	if config.KeyID != "" {
+		token.Header["kid"] = config.KeyID
+	}
+	signed, err := token.SignedString(config.Key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
@@ -0,0 +1,208 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMACAuth_SignsRequest(t *testing.T) {
+	secret := []byte("partner-secret")
+	var received http.Header
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		body, _ := ioutil.ReadAll(r.Body)
+		receivedBody = string(body)
+	}))
+	defer server.Close()
+
+	provider, err := NewHMACAuth(HMACConfig{KeyID: "k1", Secret: secret})
+	assert.Nil(t, err)
+	provider.(*hmacAuth).now = func() time.Time { return time.Unix(1700000000, 0) }
+	provider.(*hmacAuth).nonce = func() (string, error) { return "n1", nil }
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logger, OptAuth: provider})
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/orders?b=2&a=1", strings.NewReader(`{"id":1}`))
+	_, err = doer.Do(req)
+	assert.Nil(t, err)
+
+	bodyHash := sha256.Sum256([]byte(`{"id":1}`))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("POST\n/orders?b=2&a=1\n1700000000\nn1\n" + hex.EncodeToString(bodyHash[:])))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, `{"id":1}`, receivedBody)
+	assert.Equal(t, "k1", received.Get("X-Key-Id"))
+	assert.Equal(t, "1700000000", received.Get("X-Timestamp"))
+	assert.Equal(t, "n1", received.Get("X-Nonce"))
+	assert.Equal(t, signature, received.Get("X-Signature"))
+
+	assert.Empty(t, req.Header.Get("X-Signature"))
+	entry, _ := logger.LastEntry()
+	assert.NotContains(t, fmt.Sprint(entry.Fields), signature)
+}
+
+func TestHMACAuth_NeedsSecret(t *testing.T) {
+	_, err := NewHMACAuth(HMACConfig{})
+	assert.NotNil(t, err)
+}
+
+func TestClientCredentialsAuth_CachesToken(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenRequests, 1)
+		id, secret, _ := r.BasicAuth()
+		r.ParseForm()
+		if id != "client" || secret != "s3cret" || r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "orders:read orders:write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, atomic.LoadInt32(&tokenRequests))
+	}))
+	defer tokenServer.Close()
+
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	provider, err := NewClientCredentialsAuth(ClientCredentialsConfig{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"orders:read", "orders:write"},
+	})
+	assert.Nil(t, err)
+	now := time.Now()
+	provider.(*tokenCache).now = func() time.Time { return now }
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logger, OptAuth: provider})
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		_, err = doer.Do(req)
+		assert.Nil(t, err)
+	}
+
+	// refreshed before expiry
+	now = now.Add(time.Hour - 10*time.Second)
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err = doer.Do(req)
+	assert.Nil(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tokenRequests))
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, authorizations)
+	for _, entry := range logger.Entries() {
+		assert.NotContains(t, fmt.Sprint(entry.Fields), "token-")
+	}
+}
+
+func TestClientCredentialsAuth_TokenError(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer tokenServer.Close()
+
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	provider, _ := NewClientCredentialsAuth(ClientCredentialsConfig{TokenURL: tokenServer.URL, ClientID: "client"})
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logtest.New(), OptAuth: provider})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := doer.Do(req)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "status 401")
+	assert.False(t, called)
+}
+
+func TestJWTBearerAuth_SignsToken(t *testing.T) {
+	key := []byte("jwt-key")
+	provider, err := NewJWTBearerAuth(JWTBearerConfig{
+		Issuer:   "orders",
+		Audience: "partner",
+		Key:      key,
+		KeyID:    "k1",
+		Claims:   map[string]interface{}{"scope": "read"},
+		TTL:      time.Minute,
+	})
+	assert.Nil(t, err)
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://partner/orders", nil)
+	assert.Nil(t, provider.Authenticate(req))
+
+	token, err := jwt.Parse(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), func(token *jwt.Token) (interface{}, error) {
+		return key, nil
+	})
+	assert.Nil(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, "orders", claims["iss"])
+	assert.Equal(t, "partner", claims["aud"])
+	assert.Equal(t, "read", claims["scope"])
+	assert.Equal(t, "k1", token.Header["kid"])
+
+	// reused until shortly before expiry
+	again, _ := http.NewRequest(http.MethodGet, "http://partner/orders", nil)
+	provider.Authenticate(again)
+	assert.Equal(t, req.Header.Get("Authorization"), again.Header.Get("Authorization"))
+}
+
+func TestJWTBearerAuth_InvalidKey(t *testing.T) {
+	_, err := NewJWTBearerAuth(JWTBearerConfig{Key: "not bytes"})
+	assert.NotNil(t, err)
+}
+
+func TestAuthProvider_SignsEveryAttempt(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptClient: server.Client(),
+		OptLogger: logtest.New(),
+		OptRetry:  &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+		OptAuth:   NewAPIKeyAuth("X-Api-Key", "key"),
+	})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, status, err := doer.DoV2(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestAuthFunc_Error(t *testing.T) {
+	failure := errors.New("secret store down")
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptLogger: logtest.New(),
+		OptAuth:   AuthFunc(func(request *http.Request) error { return failure }),
+	})
+	req, _ := http.NewRequest(http.MethodGet, "http://partner/orders", nil)
+	_, err := doer.Do(req)
+
+	assert.True(t, errors.Is(err, failure))
+}
@@ -0,0 +1,401 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+)
+
+// ErrNoInstance is returned when the service of a load balanced request has no instance
+var ErrNoInstance = errors.New("httpclient: no instance available")
+
+const (
+	defaultBalancerRefreshInterval = 10 * time.Second
+	defaultBalancerEjectAfter      = 5
+	defaultBalancerEjectDuration   = 30 * time.Second
+	defaultBalancerAttempts        = 2
+)
+
+type BalancerStrategy int
+
+const (
+	RoundRobin = BalancerStrategy(iota)
+	// LeastOutstanding picks the instance with the fewest requests in flight, a request is in flight until its body is closed
+	LeastOutstanding
+	// PowerOfTwoChoices picks the instance with fewer requests in flight out of two random ones
+	PowerOfTwoChoices
+)
+
+// LoadBalancerConfig spreads requests to the host Service over the instances returned by Resolver.
+// An instance failing EjectAfter times in a row is left out for EjectDuration, unless every instance is.
+type LoadBalancerConfig struct {
+	Service         string           // host of the balanced requests, e.g. "uuid" for http://uuid/uui/cluster, required
+	Resolver        Resolver         // instances of Service, required
+	Strategy        BalancerStrategy // default value: RoundRobin
+	RefreshInterval time.Duration    // interval after a successful resolve, instances are refreshed in the background, default value: 10s
+	EjectAfter      int              // consecutive failures ejecting an instance, default value: 5
+	EjectDuration   time.Duration    // default value: 30s
+	// Attempts is the instances tried per request, a failed request is sent to another instance
+	// when it is idempotent or has an Idempotency-Key header, default value: 2
+	Attempts  int
+	IsFailure func(response *http.Response, err error) bool // default value: network error or 5xx status
+	Logger    log.SLogger                                   // logs ejections and resolve errors, default value: log.NewSLogger("httpclient")
+	Hedge     *HedgePolicy                                  // if undefined, requests are not hedged
+}
+
+type instance struct {
+	base         *url.URL
+	outstanding  int
+	failures     int
+	ejectedUntil time.Time
+}
+
+type loadBalancer struct {
+	doer      HttpDoer
+	config    LoadBalancerConfig
+	now       func() time.Time
+	latencies *latencyWindow
+
+	mu          sync.Mutex
+	instances   []*instance
+	next        int
+	refreshedAt time.Time
+	// resolved is closed when the resolve in flight ends, nil when none is
+	resolved chan struct{}
+}
+
+// NewLoadBalancedDoer returns doer sending requests to the host config.Service through doer to an instance of the service,
+// other requests are sent through doer as is. Any client built on HttpDoer can use it, e.g. uuid.NewClient with base URL http://uuid.
+func NewLoadBalancedDoer(doer HttpDoer, config LoadBalancerConfig) (HttpDoer, error) {
+	if config.Service == "" || config.Resolver == nil {
+		return nil, errors.New("httpclient: load balancer needs service and resolver")
+	}
+
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = defaultBalancerRefreshInterval
+	}
+	if config.EjectAfter <= 0 {
+		config.EjectAfter = defaultBalancerEjectAfter
+	}
+	if config.EjectDuration <= 0 {
+		config.EjectDuration = defaultBalancerEjectDuration
+	}
+	if config.Attempts <= 0 {
+		config.Attempts = defaultBalancerAttempts
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(response *http.Response, err error) bool {
+			return err != nil || response == nil || response.StatusCode >= http.StatusInternalServerError
+		}
+	}
+	if config.Logger == nil {
+		config.Logger = log.NewSLogger("httpclient")
+	}
+
+	config.Hedge = newHedgePolicy(config.Hedge)
+
+	return &loadBalancer{
+		doer:      doer,
+		config:    config,
+		now:       time.Now,
+		latencies: &latencyWindow{},
+	}, nil
+}
+
+func (b *loadBalancer) Do(req *http.Request) ([]byte, error) {
+	resp, err := b.DoRawResponse(req)
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (b *loadBalancer) DoV2(req *http.Request) ([]byte, int, error) {
+	resp, err := b.DoRawResponse(req)
+	if err != nil || resp == nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return respBytes, resp.StatusCode, nil
+}
+
+func (b *loadBalancer) DoRawResponse(req *http.Request) (*http.Response, error) {
+	return b.do(req, b.doer.DoRawResponse)
+}
+
+func (b *loadBalancer) DoRawResponseWithoutLogging(req *http.Request) (*HTTPCallInfo, error) {
+	// hedged attempts run concurrently, the call info returned is found by the response or error of the winner
+	var mu sync.Mutex
+	infos := make(map[interface{}]*HTTPCallInfo)
+	response, err := b.do(req, func(request *http.Request) (*http.Response, error) {
+		info, err := b.doer.DoRawResponseWithoutLogging(request)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if info.Response != nil {
+			infos[info.Response] = info
+		} else {
+			infos[info.HttpError] = info
+		}
+		return info.Response, info.HttpError
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if info, ok := infos[response]; ok && response != nil {
+		return info, nil
+	}
+	if info, ok := infos[err]; ok && err != nil {
+		return info, nil
+	}
+	return nil, err
+}
+
+func (b *loadBalancer) do(request *http.Request, send func(request *http.Request) (*http.Response, error)) (*http.Response, error) {
+	if request.URL.Host != b.config.Service {
+		return send(request)
+	}
+	b.refresh(request.Context())
+
+	retry := replayable(request, false)
+	if retry && b.config.Hedge != nil {
+		return b.doHedged(request, send)
+	}
+	tried := make(map[*instance]bool)
+
+	var response *http.Response
+	var err error
+	for attempt := 1; ; attempt++ {
+		picked := b.pick(tried)
+		if picked == nil {
+			if attempt == 1 {
+				return nil, b.noInstance()
+			}
+			return response, err
+		}
+		tried[picked] = true
+
+		outgoing, reqErr := b.outgoing(request, picked, attempt)
+		if reqErr != nil {
+			b.release(picked)
+			return response, reqErr
+		}
+		if response != nil {
+			response.Body.Close()
+		}
+
+		started := time.Now()
+		response, err = send(outgoing)
+		failed := b.config.IsFailure(response, err)
+		if !failed {
+			b.latencies.observe(time.Since(started))
+		}
+		// an open circuit of the instance doesn't tell more about its health, it is only skipped
+		b.record(request.Context(), picked, failed && !errors.Is(err, ErrCircuitOpen))
+
+		if response != nil && response.Body != nil {
+			response.Body = &releaseBody{ReadCloser: response.Body, release: func() { b.release(picked) }}
+		} else {
+			b.release(picked)
+		}
+
+		if !failed || !retry || attempt >= b.config.Attempts || request.Context().Err() != nil {
+			return response, err
+		}
+	}
+}
+
+func (b *loadBalancer) noInstance() error {
+	return fmt.Errorf("%w: %s", ErrNoInstance, b.config.Service)
+}
+
+// outgoing returns copy of request sent to the instance, with a new body for every attempt after the first one
+func (b *loadBalancer) outgoing(request *http.Request, picked *instance, attempt int) (*http.Request, error) {
+	outgoing := request.Clone(request.Context())
+	outgoing.Host = ""
+	outgoing.URL.Scheme = picked.base.Scheme
+	outgoing.URL.Host = picked.base.Host
+	if prefix := strings.TrimSuffix(picked.base.Path, "/"); prefix != "" {
+		outgoing.URL.Path = prefix + request.URL.Path
+		outgoing.URL.RawPath = ""
+	}
+
+	if attempt > 1 && request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		outgoing.Body = body
+	}
+	return outgoing, nil
+}
+
+// pick returns instance not in tried by the strategy and counts a request in flight on it, nil when every instance was tried
+func (b *loadBalancer) pick(tried map[*instance]bool) *instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	var candidates, ejected []*instance
+	for _, i := range b.instances {
+		switch {
+		case tried[i]:
+		case now.Before(i.ejectedUntil):
+			ejected = append(ejected, i)
+		default:
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = ejected
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var picked *instance
+	switch b.config.Strategy {
+	case LeastOutstanding:
+		picked = candidates[0]
+		for _, i := range candidates[1:] {
+			if i.outstanding < picked.outstanding {
+				picked = i
+			}
+		}
+	case PowerOfTwoChoices:
+		picked = candidates[rand.Intn(len(candidates))]
+		if len(candidates) > 1 {
+			other := candidates[rand.Intn(len(candidates)-1)]
+			if other == picked {
+				other = candidates[len(candidates)-1]
+			}
+			if other.outstanding < picked.outstanding {
+				picked = other
+			}
+		}
+	default:
+		picked = candidates[b.next%len(candidates)]
+		b.next++
+	}
+
+	picked.outstanding++
+	return picked
+}
+
+func (b *loadBalancer) record(ctx context.Context, i *instance, failed bool) {
+	b.mu.Lock()
+	if !failed {
+		i.failures = 0
+		b.mu.Unlock()
+		return
+	}
+
+	i.failures++
+	ejected := i.failures >= b.config.EjectAfter
+	if ejected {
+		i.failures = 0
+		i.ejectedUntil = b.now().Add(b.config.EjectDuration)
+	}
+	b.mu.Unlock()
+
+	if ejected {
+		b.config.Logger.Warnf(ctx, "instance %s of %s ejected for %s", i.base, b.config.Service, b.config.EjectDuration)
+	}
+}
+
+func (b *loadBalancer) release(i *instance) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i.outstanding--
+}
+
+// refresh resolves the instances in the background when RefreshInterval passed since the last successful resolve.
+// Without instances a resolve is started whenever none is in flight, and the caller waits for it until ctx is done.
+func (b *loadBalancer) refresh(ctx context.Context) {
+	b.mu.Lock()
+	empty := len(b.instances) == 0
+	if b.resolved == nil && (empty || b.now().Sub(b.refreshedAt) >= b.config.RefreshInterval) {
+		b.resolved = make(chan struct{})
+		go b.resolve(b.resolved)
+	}
+	resolved := b.resolved
+	b.mu.Unlock()
+
+	if empty && resolved != nil {
+		select {
+		case <-resolved:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// resolve replaces the instances and closes resolved, an instance still resolved keeps its requests in flight, failures and ejection.
+// It doesn't run on the context of a request, a canceled request doesn't fail the resolve other requests wait for.
+func (b *loadBalancer) resolve(resolved chan struct{}) {
+	ctx := context.Background()
+	addresses, err := b.config.Resolver.Resolve(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer close(resolved)
+	b.resolved = nil
+
+	if err != nil {
+		b.config.Logger.Errorf(ctx, "resolve instances of %s: %v", b.config.Service, err)
+		return
+	}
+	b.refreshedAt = b.now()
+
+	current := make(map[string]*instance, len(b.instances))
+	for _, i := range b.instances {
+		current[i.base.String()] = i
+	}
+
+	instances := make([]*instance, 0, len(addresses))
+	for _, address := range addresses {
+		base, err := url.Parse(address)
+		if err != nil || base.Host == "" {
+			b.config.Logger.Errorf(ctx, "invalid instance %q of %s", address, b.config.Service)
+			continue
+		}
+		if i, ok := current[base.String()]; ok {
+			instances = append(instances, i)
+			continue
+		}
+		instances = append(instances, &instance{base: base})
+	}
+	b.instances = instances
+}
+
+// releaseBody calls release once when the response body is closed
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
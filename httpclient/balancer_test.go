@@ -0,0 +1,250 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingServer struct {
+	*httptest.Server
+	hits int32
+}
+
+func newCountingServer(status int) *countingServer {
+	s := &countingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.hits, 1)
+		w.WriteHeader(status)
+		w.Write([]byte(r.URL.Path))
+	}))
+	return s
+}
+
+func newBalancerTestDoer(t *testing.T, config LoadBalancerConfig) (HttpDoer, *logtest.Logger) {
+	logger := logtest.New()
+	config.Logger = logger
+	doer, err := NewLoadBalancedDoer(NewHttpDoWithParam(&ClientParam{OptClient: &http.Client{}, OptLogger: logtest.New()}), config)
+	assert.Nil(t, err)
+	return doer, logger
+}
+
+func TestLoadBalancer_RoundRobin(t *testing.T) {
+	servers := []*countingServer{newCountingServer(http.StatusOK), newCountingServer(http.StatusOK), newCountingServer(http.StatusOK)}
+	for _, s := range servers {
+		defer s.Close()
+	}
+
+	doer, _ := newBalancerTestDoer(t, LoadBalancerConfig{
+		Service:  "uuid",
+		Resolver: NewStaticResolver(servers[0].URL, servers[1].URL, servers[2].URL),
+	})
+
+	for i := 0; i < 6; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://uuid/uui/cluster", nil)
+		body, status, err := doer.DoV2(req)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "/uui/cluster", string(body))
+	}
+	for _, s := range servers {
+		assert.Equal(t, int32(2), atomic.LoadInt32(&s.hits))
+	}
+}
+
+func TestLoadBalancer_RetriesAndEjectsFailingInstance(t *testing.T) {
+	failing, healthy := newCountingServer(http.StatusServiceUnavailable), newCountingServer(http.StatusOK)
+	defer failing.Close()
+	defer healthy.Close()
+
+	doer, logger := newBalancerTestDoer(t, LoadBalancerConfig{
+		Service:    "uuid",
+		Resolver:   NewStaticResolver(failing.URL, healthy.URL),
+		EjectAfter: 2,
+	})
+
+	for i := 0; i < 6; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://uuid/", nil)
+		_, status, err := doer.DoV2(req)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&failing.hits))
+	assert.Equal(t, int32(6), atomic.LoadInt32(&healthy.hits))
+	logger.AssertLogged(t, logrus.WarnLevel, "ejected", nil)
+
+	// not idempotent, the failure is returned
+	req, _ := http.NewRequest(http.MethodPost, "http://uuid/", nil)
+	balancer := doer.(*loadBalancer)
+	balancer.instances[0].ejectedUntil = time.Time{}
+	balancer.next = 0
+	_, status, _ := doer.DoV2(req)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestLoadBalancer_LeastOutstanding(t *testing.T) {
+	first, second := newCountingServer(http.StatusOK), newCountingServer(http.StatusOK)
+	defer first.Close()
+	defer second.Close()
+
+	doer, _ := newBalancerTestDoer(t, LoadBalancerConfig{
+		Service:  "uuid",
+		Resolver: NewStaticResolver(first.URL, second.URL),
+		Strategy: LeastOutstanding,
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://uuid/", nil)
+	open, err := doer.DoRawResponse(req)
+	assert.Nil(t, err)
+
+	// the body of the first response is still open, so its instance is busy
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://uuid/", nil)
+		_, err := doer.Do(req)
+		assert.Nil(t, err)
+	}
+	open.Body.Close()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&first.hits))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&second.hits))
+}
+
+func TestLoadBalancer_PassThroughAndNoInstance(t *testing.T) {
+	other := newCountingServer(http.StatusOK)
+	defer other.Close()
+
+	doer, logger := newBalancerTestDoer(t, LoadBalancerConfig{
+		Service:  "uuid",
+		Resolver: NewFileResolver(filepath.Join(t.TempDir(), "missing")),
+		Strategy: PowerOfTwoChoices,
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, other.URL, nil)
+	_, err := doer.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&other.hits))
+
+	req, _ = http.NewRequest(http.MethodGet, "http://uuid/", nil)
+	_, err = doer.Do(req)
+	assert.True(t, errors.Is(err, ErrNoInstance))
+	logger.AssertLogged(t, logrus.ErrorLevel, "resolve instances of uuid", nil)
+}
+
+// blockingResolver returns addresses once release is closed, ignoring the context of the request
+type blockingResolver struct {
+	release   chan struct{}
+	calls     int32
+	addresses []string
+}
+
+func (r *blockingResolver) Resolve(ctx context.Context) ([]string, error) {
+	atomic.AddInt32(&r.calls, 1)
+	<-r.release
+	return r.addresses, nil
+}
+
+func TestLoadBalancer_FirstRequestsWaitForResolve(t *testing.T) {
+	server := newCountingServer(http.StatusOK)
+	defer server.Close()
+
+	resolver := &blockingResolver{release: make(chan struct{}), addresses: []string{server.URL}}
+	doer, _ := newBalancerTestDoer(t, LoadBalancerConfig{Service: "uuid", Resolver: resolver})
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			req, _ := http.NewRequest(http.MethodGet, "http://uuid/", nil)
+			_, err := doer.Do(req)
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(resolver.release)
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, <-errs)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&resolver.calls))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&server.hits))
+}
+
+func TestLoadBalancer_ResolvesAgainAfterFailure(t *testing.T) {
+	server := newCountingServer(http.StatusOK)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "instances")
+	doer, _ := newBalancerTestDoer(t, LoadBalancerConfig{Service: "uuid", Resolver: NewFileResolver(path), RefreshInterval: time.Hour})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://uuid/", nil)
+	_, err := doer.Do(req)
+	assert.True(t, errors.Is(err, ErrNoInstance))
+
+	// a failed resolve doesn't wait for RefreshInterval
+	assert.Nil(t, ioutil.WriteFile(path, []byte(server.URL+"\n"), 0644))
+	req, _ = http.NewRequest(http.MethodGet, "http://uuid/", nil)
+	_, err = doer.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.hits))
+}
+
+func TestLoadBalancer_CanceledRequestDoesNotFailResolve(t *testing.T) {
+	server := newCountingServer(http.StatusOK)
+	defer server.Close()
+
+	resolver := &blockingResolver{release: make(chan struct{}), addresses: []string{server.URL}}
+	doer, _ := newBalancerTestDoer(t, LoadBalancerConfig{Service: "uuid", Resolver: resolver})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://uuid/", nil)
+	_, err := doer.Do(req)
+	assert.NotNil(t, err)
+
+	close(resolver.release)
+	req, _ = http.NewRequest(http.MethodGet, "http://uuid/", nil)
+	_, err = doer.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&resolver.calls))
+}
+
+func TestFileResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instances")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("# uuid\nhttp://10.0.0.1:8080\n\nhttp://10.0.0.2:8080\n"), 0644))
+
+	resolver := NewFileResolver(path)
+	instances, err := resolver.Resolve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"}, instances)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("http://10.0.0.3:8080\n"), 0644))
+	later := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(path, later, later))
+
+	instances, err = resolver.Resolve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"http://10.0.0.3:8080"}, instances)
+}
+
+func TestSRVResolver(t *testing.T) {
+	resolver := NewSRVResolver("_http._tcp.uuid.svc", "").(*srvResolver)
+	resolver.lookup = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		assert.Equal(t, "_http._tcp.uuid.svc", name)
+		return "", []*net.SRV{{Target: "uuid-0.uuid.svc.", Port: 8080}, {Target: "uuid-1.uuid.svc.", Port: 8080}}, nil
+	}
+
+	instances, err := resolver.Resolve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"http://uuid-0.uuid.svc:8080", "http://uuid-1.uuid.svc:8080"}, instances)
+}
@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+const (
+	FieldReqSize      = "req_size"
+	FieldResponseSize = "rsp_size"
+
+	truncatedSuffix = "...(truncated)"
+)
+
+// bodyCapture keeps the first max bytes written to it and counts every byte
+type bodyCapture struct {
+	max       int
+	buf       bytes.Buffer
+	size      int64
+	truncated bool
+	// partial is set when the body was not read to the end, size is then unknown
+	partial bool
+}
+
+func newBodyCapture(max int) *bodyCapture {
+	return &bodyCapture{max: max}
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	c.size += int64(len(p))
+
+	keep := p
+	if remaining := c.max - c.buf.Len(); len(keep) > remaining {
+		keep = keep[:remaining]
+		c.truncated = true
+	}
+	c.buf.Write(keep)
+	return len(p), nil
+}
+
+// String returns the captured body, marked with truncatedSuffix when it was cut at max
+func (c *bodyCapture) String() string {
+	if c.truncated {
+		return c.buf.String() + truncatedSuffix
+	}
+	return c.buf.String()
+}
+
+// teeBody captures the request body while the transport sends it
+type teeBody struct {
+	io.ReadCloser
+	capture *bodyCapture
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.capture.Write(p[:n])
+	return n, err
+}
+
+// logBody passes the response body through to the caller and calls done once, at EOF or on Close
+type logBody struct {
+	io.ReadCloser
+	capture *bodyCapture
+	once    sync.Once
+	done    func(capture *bodyCapture)
+}
+
+func (b *logBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.capture.Write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *logBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *logBody) finish() {
+	b.once.Do(func() {
+		b.done(b.capture)
+	})
+}
+
+// captureRequestBody returns the body sent with request, nil when there is none or the request body is not logged.
+// Replayable bodies are copied through GetBody up to the max body size, other bodies are captured while sent.
+func (d *httpDoer) captureRequestBody(request *http.Request) *bodyCapture {
+	if request.Body == nil || request.Body == http.NoBody || !d.logConfig.LogRequestBody() {
+		return nil
+	}
+
+	capture := newBodyCapture(d.logConfig.maxBodySize())
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil
+		}
+		defer body.Close()
+
+		if _, err := io.Copy(capture, io.LimitReader(body, int64(capture.max)+1)); err != nil {
+			return nil
+		}
+		capture.partial = capture.truncated
+		return capture
+	}
+
+	request.Body = &teeBody{ReadCloser: request.Body, capture: capture}
+	return capture
+}
+
+// logOnBodyDone calls done with the response body captured for logging, right away unless the response is a stream.
+// A logged body is peeked up to the max body size and handed back unread, a stream passes through untouched
+// and done is called once the caller has read it to EOF or closed it, with its size.
+func (d *httpDoer) logOnBodyDone(response *http.Response, done func(responseBody *bodyCapture)) {
+	switch {
+	case response.Body == nil || response.Body == http.NoBody || response.StatusCode == http.StatusSwitchingProtocols:
+		done(nil)
+	case d.logConfig.isStream(response):
+		response.Body = &logBody{ReadCloser: response.Body, capture: newBodyCapture(0), done: done}
+	case d.logConfig.LogResponseBody():
+		done(peekBody(response, d.logConfig.maxBodySize()))
+	default:
+		done(nil)
+	}
+}
+
+// peekBody reads up to max bytes of the response body, the caller still reads the whole body
+func peekBody(response *http.Response, max int) *bodyCapture {
+	prefix, _ := ioutil.ReadAll(io.LimitReader(response.Body, int64(max)+1))
+	response.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(prefix), response.Body), Closer: response.Body}
+
+	capture := newBodyCapture(max)
+	capture.Write(prefix)
+	capture.partial = capture.truncated
+	return capture
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+func (c *LogConfig) isStream(response *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, stream := range c.StreamContentTypes {
+		if mediaType == stream {
+			return true
+		}
+	}
+	return false
+}
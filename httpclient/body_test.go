@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpDoer_LogsTruncatedBodies(t *testing.T) {
+	responseBody := strings.Repeat("r", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte(responseBody))
+	}))
+	defer server.Close()
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptClient:  server.Client(),
+		OptLogger:  logger,
+		OptLogConf: &LogConfig{MaxBodySize: 10},
+	})
+
+	// a reader without GetBody is captured while sent
+	req, _ := http.NewRequest(http.MethodPost, server.URL, ioutil.NopCloser(strings.NewReader(strings.Repeat("q", 50))))
+	body, err := doer.Do(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, responseBody, string(body))
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldReqBody:      strings.Repeat("q", 10) + truncatedSuffix,
+		FieldReqSize:          50,
+		log.FieldResponseBody: strings.Repeat("r", 10) + truncatedSuffix,
+		FieldResponseSize:     100,
+	})
+}
+
+func TestHttpDoer_RedactsTruncatedJSONBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"id":1,"access_token":"secret-token-value"}`))
+	}))
+	defer server.Close()
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptClient:  server.Client(),
+		OptLogger:  logger,
+		OptLogConf: &LogConfig{MaxBodySize: 30},
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"user":"a","password":"secret-password"}`))
+	_, err := doer.Do(req)
+
+	assert.Nil(t, err)
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldReqBody:      `{"user":"a","password":"[REDACTED]`,
+		log.FieldResponseBody: `{"id":1,"access_token":"[REDACTED]`,
+	})
+}
+
+func TestHttpDoer_CapturesReplayableBodyUpToMax(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptClient:  server.Client(),
+		OptLogger:  logger,
+		OptLogConf: &LogConfig{MaxBodySize: 10},
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(strings.Repeat("q", 50)))
+	_, err := doer.Do(req)
+
+	assert.Nil(t, err)
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldReqBody: strings.Repeat("q", 10) + truncatedSuffix,
+		FieldReqSize:     50,
+	})
+}
+
+func TestHttpDoer_DoesNotCopyExcludedRequestBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptClient:  server.Client(),
+		OptLogger:  logtest.New(),
+		OptLogConf: &LogConfig{ExcludeOpt: &ExcludeOption{RequestBody: ExcludeLog}},
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("secret"))
+	getBody, copies := req.GetBody, 0
+	req.GetBody = func() (io.ReadCloser, error) {
+		copies++
+		return getBody()
+	}
+	_, err := doer.Do(req)
+
+	assert.Nil(t, err)
+	// only the re-attach after the call, the body is not copied for logging
+	assert.Equal(t, 1, copies)
+}
+
+func TestHttpDoer_DoRawResponse_DoesNotReadExcludedBody(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "4")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptClient:  server.Client(),
+		OptLogger:  logger,
+		OptLogConf: &LogConfig{ExcludeOpt: &ExcludeOption{ResponseBody: ExcludeLog}},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	returned := make(chan *http.Response, 1)
+	go func() {
+		response, _ := doer.DoRawResponse(req)
+		returned <- response
+	}()
+
+	select {
+	case response := <-returned:
+		defer response.Body.Close()
+		logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+			log.FieldResponseBody: wipedMessage,
+			FieldResponseSize:     4,
+		})
+	case <-time.After(time.Second):
+		t.Fatal("DoRawResponse waited for the response body")
+	}
+}
+
+func TestHttpDoer_LogsStreamOnClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logger})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	response, err := doer.DoRawResponse(req)
+	assert.Nil(t, err)
+
+	_, logged := logger.LastEntry()
+	assert.False(t, logged)
+
+	events, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	assert.Equal(t, "data: 0\n\ndata: 1\n\ndata: 2\n\n", string(events))
+	assert.Len(t, logger.Entries(), 1)
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldResponseBody: wipedMessage,
+		FieldResponseSize:     len(events),
+	})
+}
@@ -0,0 +1,452 @@
+package httpclient
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+)
+
+const (
+	FieldCache = "cache"
+
+	defaultCacheKeyPrefix    = "httpclient:"
+	defaultCacheKeepStale    = time.Hour
+	defaultCacheMaxEntrySize = 1 << 20
+	defaultMemoryCacheSize   = 1000
+)
+
+// CacheStatus tells how a response was served by the cache, empty when the request is not cacheable
+type CacheStatus string
+
+const (
+	CacheMiss CacheStatus = "miss"
+	CacheHit  CacheStatus = "hit"
+	// CacheRevalidated is a stale response confirmed with a 304 Not Modified
+	CacheRevalidated CacheStatus = "revalidated"
+)
+
+// cacheableStatusCodes are stored when the response allows it, other status codes are never stored
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// CacheStore keeps cached responses, any cache.Cacher, e.g. Redis, is a CacheStore
+type CacheStore interface {
+	Set(key, value string, ttl time.Duration) error
+	Get(key string) (string, error)
+	Del(key ...string) error
+	ErrorOnCacheMiss() error
+}
+
+// CacheConfig caches GET responses as a private cache following Cache-Control, Expires and Vary.
+// A stale response with ETag or Last-Modified is revalidated with If-None-Match or If-Modified-Since.
+// Requests with an Authorization header, and every request of a doer with OptAuth, are cached only when the response is public,
+// a successful request of another method removes the cached response of its url.
+// The body is read for storing only once the header allows it, responses of LogConfig.StreamContentTypes are never stored.
+type CacheConfig struct {
+	Store     CacheStore // default value: NewMemoryCacheStore(1000)
+	KeyPrefix string     // prefix of the store keys, default value: "httpclient:"
+	// RouteTTL overrides the freshness given by the response, by route template set with WithRoute, e.g. "/configs/{name}",
+	// no-store responses are still not stored
+	RouteTTL     map[string]time.Duration
+	KeepStale    time.Duration // time a stale response with ETag or Last-Modified is kept for revalidation, default value: 1h
+	MaxEntrySize int           // longest body stored, default value: 1 MiB
+}
+
+type responseCache struct {
+	config CacheConfig
+	// authenticated is set when requests get credentials from OptAuth, they are treated like an Authorization header
+	authenticated bool
+	// logConfig gives the StreamContentTypes, their responses are never buffered
+	logConfig *LogConfig
+	logger    log.SLogger
+	now       func() time.Time
+}
+
+func newResponseCache(config *CacheConfig, authenticated bool, logConfig *LogConfig, logger log.SLogger) *responseCache {
+	if config == nil {
+		return nil
+	}
+
+	c := &responseCache{config: *config, authenticated: authenticated, logConfig: logConfig, logger: logger, now: time.Now}
+	if c.config.Store == nil {
+		c.config.Store = NewMemoryCacheStore(defaultMemoryCacheSize)
+	}
+	if c.config.KeyPrefix == "" {
+		c.config.KeyPrefix = defaultCacheKeyPrefix
+	}
+	if c.config.KeepStale <= 0 {
+		c.config.KeepStale = defaultCacheKeepStale
+	}
+	if c.config.MaxEntrySize <= 0 {
+		c.config.MaxEntrySize = defaultCacheMaxEntrySize
+	}
+	return c
+}
+
+type cacheEntry struct {
+	StatusCode int               `json:"status_code"`
+	Header     http.Header       `json:"header"`
+	Body       []byte            `json:"body,omitempty"`
+	Vary       map[string]string `json:"vary,omitempty"`
+	StoredAt   time.Time         `json:"stored_at"`
+	FreshUntil time.Time         `json:"fresh_until"`
+}
+
+// cacheLookup is the cached response of a request, entry is nil on a miss
+type cacheLookup struct {
+	key   string
+	entry *cacheEntry
+	fresh bool
+}
+
+func (c *responseCache) key(request *http.Request) string {
+	return c.config.KeyPrefix + request.URL.String()
+}
+
+// lookup returns the cached response of a GET request, nil when the request is not cacheable
+func (c *responseCache) lookup(request *http.Request) *cacheLookup {
+	if c == nil || request.Method != http.MethodGet {
+		return nil
+	}
+	directives := cacheControl(request.Header)
+	if _, ok := directives["no-store"]; ok {
+		return nil
+	}
+
+	lookup := &cacheLookup{key: c.key(request)}
+	value, err := c.config.Store.Get(lookup.key)
+	if err != nil {
+		if !errors.Is(err, c.config.Store.ErrorOnCacheMiss()) {
+			c.logger.Warnf(request.Context(), "response cache get %s: %v", lookup.key, err)
+		}
+		return lookup
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal([]byte(value), entry); err != nil {
+		c.logger.Warnf(request.Context(), "response cache entry %s: %v", lookup.key, err)
+		return lookup
+	}
+	for name, value := range entry.Vary {
+		if request.Header.Get(name) != value {
+			return lookup
+		}
+	}
+
+	lookup.entry = entry
+	_, noCache := directives["no-cache"]
+	lookup.fresh = !noCache && directives["max-age"] != "0" && c.now().Before(entry.FreshUntil)
+	return lookup
+}
+
+// conditional returns copy of request revalidating the stale cached response, request itself when there is nothing to revalidate
+func (l *cacheLookup) conditional(request *http.Request) *http.Request {
+	if l == nil || l.entry == nil {
+		return request
+	}
+	etag, lastModified := l.entry.Header.Get("ETag"), l.entry.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return request
+	}
+
+	conditional := request.Clone(request.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+	return conditional
+}
+
+// response returns the cached response of request, with an Age header
+func (c *responseCache) response(request *http.Request, entry *cacheEntry) *http.Response {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(c.now().Sub(entry.StoredAt).Seconds())))
+	return &http.Response{
+		Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       request,
+	}
+}
+
+// update stores response of request when cacheable and returns the response for the caller,
+// the cached response when a stale one was revalidated
+func (c *responseCache) update(request *http.Request, lookup *cacheLookup, response *http.Response) (*http.Response, CacheStatus) {
+	if c == nil || response == nil {
+		return response, ""
+	}
+	if lookup == nil {
+		if request.Method != http.MethodGet && request.Method != http.MethodHead && response.StatusCode < http.StatusBadRequest {
+			c.delete(request.Context(), c.key(request))
+		}
+		return response, ""
+	}
+
+	if response.StatusCode == http.StatusNotModified && lookup.entry != nil {
+		response.Body.Close()
+		entry := lookup.entry
+		for name, values := range response.Header {
+			entry.Header[name] = values
+		}
+		c.store(request, lookup.key, entry)
+		return c.response(request, entry), CacheRevalidated
+	}
+
+	if cacheableStatusCodes[response.StatusCode] {
+		c.storeResponse(request, lookup.key, response)
+	}
+	return response, CacheMiss
+}
+
+// storeResponse stores response when its header allows it and its body fits MaxEntrySize, the caller still reads the whole body.
+// The body is read only after the header is found storable, streams are never read.
+func (c *responseCache) storeResponse(request *http.Request, key string, response *http.Response) {
+	if _, ok := cacheControl(response.Header)["no-store"]; ok {
+		c.delete(request.Context(), key)
+		return
+	}
+	if c.logConfig != nil && c.logConfig.isStream(response) {
+		return
+	}
+
+	entry := &cacheEntry{
+		StatusCode: response.StatusCode,
+		Header:     response.Header.Clone(),
+	}
+	ttl := c.prepare(request, entry)
+	if ttl <= 0 {
+		return
+	}
+
+	if response.Body != nil && response.Body != http.NoBody {
+		prefix, err := ioutil.ReadAll(io.LimitReader(response.Body, int64(c.config.MaxEntrySize)+1))
+		response.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(prefix), response.Body), Closer: response.Body}
+		if err != nil || len(prefix) > c.config.MaxEntrySize {
+			return
+		}
+		entry.Body = prefix
+	}
+	c.save(request.Context(), key, entry, ttl)
+}
+
+// store saves entry with the freshness and vary of its header, see prepare
+func (c *responseCache) store(request *http.Request, key string, entry *cacheEntry) {
+	if ttl := c.prepare(request, entry); ttl > 0 {
+		c.save(request.Context(), key, entry, ttl)
+	}
+}
+
+// prepare sets the freshness and vary of entry from its header and returns how long to keep it,
+// 0 when it is neither fresh nor revalidatable, private to an authorized request or varying by every header
+func (c *responseCache) prepare(request *http.Request, entry *cacheEntry) time.Duration {
+	header := entry.Header
+	directives := cacheControl(header)
+	authorized := c.authenticated || request.Header.Get(headerAuthorization) != ""
+	if _, public := directives["public"]; authorized && !public {
+		return 0
+	}
+
+	entry.Vary = nil
+	for _, field := range header.Values("Vary") {
+		for _, name := range strings.Split(field, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return 0
+			}
+			if name == "" {
+				continue
+			}
+			if entry.Vary == nil {
+				entry.Vary = make(map[string]string)
+			}
+			entry.Vary[name] = request.Header.Get(name)
+		}
+	}
+
+	now := c.now()
+	lifetime, ok := c.config.RouteTTL[RouteFromContext(request.Context())]
+	if !ok {
+		lifetime = freshness(directives, header, now)
+	}
+	entry.StoredAt = now
+	entry.FreshUntil = now.Add(lifetime)
+
+	ttl := lifetime
+	if header.Get("ETag") != "" || header.Get("Last-Modified") != "" {
+		ttl += c.config.KeepStale
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+func (c *responseCache) save(ctx context.Context, key string, entry *cacheEntry, ttl time.Duration) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := c.config.Store.Set(key, string(value), ttl); err != nil {
+		c.logger.Warnf(ctx, "response cache set %s: %v", key, err)
+	}
+}
+
+func (c *responseCache) delete(ctx context.Context, key string) {
+	if err := c.config.Store.Del(key); err != nil && !errors.Is(err, c.config.Store.ErrorOnCacheMiss()) {
+		c.logger.Warnf(ctx, "response cache delete %s: %v", key, err)
+	}
+}
+
+// freshness returns the lifetime given by max-age, or Expires relative to Date, minus Age. no-cache is no lifetime.
+func freshness(directives map[string]string, header http.Header, now time.Time) time.Duration {
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+
+	var lifetime time.Duration
+	if maxAge, err := strconv.Atoi(directives["max-age"]); err == nil {
+		lifetime = time.Duration(maxAge) * time.Second
+	} else if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		lifetime = expires.Sub(date)
+	}
+
+	if age, err := strconv.Atoi(header.Get("Age")); err == nil {
+		lifetime -= time.Duration(age) * time.Second
+	}
+	if lifetime < 0 {
+		return 0
+	}
+	return lifetime
+}
+
+// cacheControl returns the Cache-Control directives of header, lower case, with unquoted values
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, field := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(field, ",") {
+			name, value := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name, value = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				directives[name] = value
+			}
+		}
+	}
+	return directives
+}
+
+var errMemoryCacheMiss = errors.New("httpclient: cache miss")
+
+type memoryCacheItem struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+// memoryCacheStore is a CacheStore in memory, evicting the least recently used entry beyond maxEntries
+type memoryCacheStore struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+}
+
+// NewMemoryCacheStore returns CacheStore in memory keeping up to maxEntries, for a single instance or tests
+func NewMemoryCacheStore(maxEntries int) CacheStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryCacheSize
+	}
+	return &memoryCacheStore{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (s *memoryCacheStore) Set(key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := &memoryCacheItem{key: key, value: value, expiresAt: s.now().Add(ttl)}
+	if element, ok := s.items[key]; ok {
+		element.Value = item
+		s.lru.MoveToFront(element)
+		return nil
+	}
+
+	s.items[key] = s.lru.PushFront(item)
+	if s.lru.Len() > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+func (s *memoryCacheStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return "", errMemoryCacheMiss
+	}
+	item := element.Value.(*memoryCacheItem)
+	if !s.now().Before(item.expiresAt) {
+		s.lru.Remove(element)
+		delete(s.items, key)
+		return "", errMemoryCacheMiss
+	}
+	s.lru.MoveToFront(element)
+	return item.value, nil
+}
+
+func (s *memoryCacheStore) Del(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := s.items[key]; ok {
+			s.lru.Remove(element)
+			delete(s.items, key)
+		}
+	}
+	return nil
+}
+
+func (s *memoryCacheStore) ErrorOnCacheMiss() error {
+	return errMemoryCacheMiss
+}
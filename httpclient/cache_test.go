@@ -0,0 +1,287 @@
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCacheTestDoer(server *httptest.Server, config *CacheConfig) (HttpDoer, *logtest.Logger) {
+	logger := logtest.New()
+	return NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logger, OptCache: config}), logger
+}
+
+func getBody(t *testing.T, doer HttpDoer, request *http.Request) (string, int) {
+	body, status, err := doer.DoV2(request)
+	assert.Nil(t, err)
+	return string(body), status
+}
+
+func TestResponseCache_ServesFreshResponse(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(`{"feature":true}`))
+	}))
+	defer server.Close()
+
+	doer, logger := newCacheTestDoer(server, &CacheConfig{})
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/configs/feature", nil)
+		body, status := getBody(t, doer, req)
+		assert.Equal(t, `{"feature":true}`, body)
+		assert.Equal(t, http.StatusOK, status)
+	}
+
+	assert.Equal(t, 1, calls)
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{FieldCache: "miss"})
+	assert.Len(t, logger.Find(logrus.InfoLevel, "", map[string]interface{}{FieldCache: "hit"}), 2)
+}
+
+func TestResponseCache_RevalidatesStaleResponse(t *testing.T) {
+	var conditions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("config v1"))
+	}))
+	defer server.Close()
+
+	doer, logger := newCacheTestDoer(server, &CacheConfig{})
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/configs", nil)
+		body, status := getBody(t, doer, req)
+		assert.Equal(t, "config v1", body)
+		assert.Equal(t, http.StatusOK, status)
+	}
+
+	assert.Equal(t, []string{"|", `"v1"|Mon, 02 Jan 2006 15:04:05 GMT`}, conditions)
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{FieldCache: "revalidated"})
+}
+
+func TestResponseCache_NotStored(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		response http.Header
+	}{
+		{name: "no-store response", response: http.Header{"Cache-Control": {"no-store"}}},
+		{name: "no freshness", response: http.Header{}},
+		{name: "vary all", response: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}},
+		{name: "no-store request", header: http.Header{"Cache-Control": {"no-store"}}, response: http.Header{"Cache-Control": {"max-age=60"}}},
+		{name: "authorized request", header: http.Header{"Authorization": {"Bearer user"}}, response: http.Header{"Cache-Control": {"max-age=60"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				for name, values := range tt.response {
+					w.Header()[name] = values
+				}
+			}))
+			defer server.Close()
+
+			doer, _ := newCacheTestDoer(server, &CacheConfig{})
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				for name, values := range tt.header {
+					req.Header[name] = values
+				}
+				getBody(t, doer, req)
+			}
+			assert.Equal(t, 2, calls)
+		})
+	}
+}
+
+func TestResponseCache_OptAuthStoresPublicOnly(t *testing.T) {
+	for cacheControl, calls := range map[string]int{"max-age=60": 2, "public, max-age=60": 1} {
+		t.Run(cacheControl, func(t *testing.T) {
+			served := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served++
+				w.Header().Set("Cache-Control", cacheControl)
+			}))
+			defer server.Close()
+
+			doer := NewHttpDoWithParam(&ClientParam{
+				OptClient: server.Client(),
+				OptLogger: logtest.New(),
+				OptCache:  &CacheConfig{},
+				OptAuth:   NewAPIKeyAuth("X-Api-Key", "user-key"),
+			})
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				getBody(t, doer, req)
+			}
+			assert.Equal(t, calls, served)
+		})
+	}
+}
+
+func TestResponseCache_RouteTTL(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("config"))
+	}))
+	defer server.Close()
+
+	doer, _ := newCacheTestDoer(server, &CacheConfig{RouteTTL: map[string]time.Duration{"/configs/{name}": time.Minute}})
+	for i := 0; i < 2; i++ {
+		ctx := WithRoute(context.Background(), "/configs/{name}")
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/configs/feature", nil)
+		getBody(t, doer, req)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestResponseCache_Vary(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "X-Country")
+		w.Write([]byte(r.Header.Get("X-Country")))
+	}))
+	defer server.Close()
+
+	doer, _ := newCacheTestDoer(server, &CacheConfig{})
+	for _, country := range []string{"ID", "ID", "SG", "SG"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("X-Country", country)
+		body, _ := getBody(t, doer, req)
+		assert.Equal(t, country, body)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_UnsafeMethodInvalidates(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			calls++
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+	}))
+	defer server.Close()
+
+	doer, _ := newCacheTestDoer(server, &CacheConfig{})
+	for _, method := range []string{http.MethodGet, http.MethodGet, http.MethodPut, http.MethodGet} {
+		req, _ := http.NewRequest(method, server.URL+"/configs/feature", nil)
+		getBody(t, doer, req)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_LargeBodyNotStored(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	doer, _ := newCacheTestDoer(server, &CacheConfig{MaxEntrySize: 5})
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := doer.DoRawResponse(req)
+		assert.Nil(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "0123456789", string(body))
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_UnstorableBodyNotRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+	}{
+		{name: "event stream", header: http.Header{"Cache-Control": {"max-age=60"}, "Content-Type": {"text/event-stream"}}},
+		{name: "no freshness", header: http.Header{"Cache-Control": {"no-cache"}, "Content-Type": {"text/plain"}}},
+		{name: "vary all", header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, values := range tt.header {
+					w.Header()[name] = values
+				}
+				w.Write([]byte("data: first\n\n"))
+				w.(http.Flusher).Flush()
+				<-done
+			}))
+			defer server.Close()
+			defer close(done)
+
+			doer := NewHttpDoWithParam(&ClientParam{
+				OptClient:  server.Client(),
+				OptLogger:  logtest.New(),
+				OptLogConf: &LogConfig{ExcludeOpt: &ExcludeOption{ResponseBody: true}},
+				OptCache:   &CacheConfig{},
+			})
+			responses := make(chan *http.Response, 1)
+			go func() {
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				resp, err := doer.DoRawResponse(req)
+				assert.Nil(t, err)
+				responses <- resp
+			}()
+
+			select {
+			case resp := <-responses:
+				resp.Body.Close()
+			case <-time.After(time.Second):
+				t.Fatal("response waited for the body of the stream")
+			}
+		})
+	}
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore(2).(*memoryCacheStore)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.Set("a", "1", time.Minute)
+	store.Set("b", "2", time.Second)
+	store.Get("a")
+	store.Set("c", "3", time.Minute)
+
+	// b is the least recently used
+	_, err := store.Get("b")
+	assert.Equal(t, store.ErrorOnCacheMiss(), err)
+	value, err := store.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", value)
+
+	now = now.Add(time.Minute)
+	_, err = store.Get("c")
+	assert.Equal(t, store.ErrorOnCacheMiss(), err)
+
+	store.Set("d", "4", time.Minute)
+	store.Del("d")
+	_, err = store.Get("d")
+	assert.Equal(t, store.ErrorOnCacheMiss(), err)
+}
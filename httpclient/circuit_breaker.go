@@ -0,0 +1,286 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+)
+
+// ErrCircuitOpen is returned, wrapped with the circuit key, while the circuit of an upstream is open
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+const (
+	defaultCircuitWindow         = 10 * time.Second
+	defaultCircuitMinRequests    = 20
+	defaultCircuitFailureRate    = 0.5
+	defaultCircuitOpenTimeout    = 30 * time.Second
+	defaultCircuitHalfOpenProbes = 1
+
+	// circuitBuckets splits the window so old requests leave it gradually
+	circuitBuckets = 10
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed = CircuitState(iota)
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerConfig opens the circuit of an upstream when too many calls fail in the window,
+// calls fail fast with ErrCircuitOpen until OpenTimeout, then HalfOpenProbes calls probe the upstream.
+// Calls ended by the caller's context, cancelled or past its deadline, are not recorded.
+type CircuitBreakerConfig struct {
+	Window         time.Duration                                 // failure rate window, default value: 10s
+	MinRequests    int                                           // requests in the window before the circuit may open, default value: 20
+	FailureRate    float64                                       // ratio of failed requests opening the circuit, default value: 0.5
+	OpenTimeout    time.Duration                                 // time the circuit stays open before probing, default value: 30s
+	HalfOpenProbes int                                           // concurrent probes, all must succeed to close the circuit, default value: 1
+	KeyFunc        func(request *http.Request) string            // circuit of a request, e.g. by route template, default value: request host
+	IsFailure      func(response *http.Response, err error) bool // default value: error other than context.Canceled, or 5xx status
+	OnStateChange  func(key string, from, to CircuitState)       // metrics hook, called outside of the breaker lock
+}
+
+type circuitBucket struct {
+	start    int64
+	total    int
+	failures int
+}
+
+type circuit struct {
+	state CircuitState
+	// generation changes with every transition, so a late probe can't count for the next half-open period
+	generation uint64
+	openedAt   time.Time
+	buckets    [circuitBuckets]circuitBucket
+	probes     int
+	passed     int
+}
+
+type circuitBreakers struct {
+	config CircuitBreakerConfig
+	logger log.SLogger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuitTransition struct {
+	key      string
+	from, to CircuitState
+}
+
+func newCircuitBreakers(c *CircuitBreakerConfig, logger log.SLogger) *circuitBreakers {
+	if c == nil {
+		return nil
+	}
+
+	config := *c
+	if config.Window <= 0 {
+		config.Window = defaultCircuitWindow
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = defaultCircuitMinRequests
+	}
+	if config.FailureRate <= 0 {
+		config.FailureRate = defaultCircuitFailureRate
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaultCircuitOpenTimeout
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = defaultCircuitHalfOpenProbes
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = func(request *http.Request) string { return request.URL.Host }
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(response *http.Response, err error) bool {
+			if err != nil {
+				return !errors.Is(err, context.Canceled)
+			}
+			return response == nil || response.StatusCode >= http.StatusInternalServerError
+		}
+	}
+
+	return &circuitBreakers{
+		config:   config,
+		logger:   logger,
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// allow returns the function recording the call result, or ErrCircuitOpen when request must not be sent
+func (b *circuitBreakers) allow(request *http.Request) (func(response *http.Response, err error), error) {
+	if b == nil {
+		return func(*http.Response, error) {}, nil
+	}
+
+	key := b.config.KeyFunc(request)
+
+	b.mu.Lock()
+	c := b.circuit(key)
+	var transition *circuitTransition
+	if c.state == CircuitOpen && b.now().Sub(c.openedAt) >= b.config.OpenTimeout {
+		transition = b.setState(key, c, CircuitHalfOpen)
+	}
+
+	probe, generation := false, c.generation
+	switch c.state {
+	case CircuitOpen:
+		b.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, key)
+	case CircuitHalfOpen:
+		if c.probes >= b.config.HalfOpenProbes {
+			b.mu.Unlock()
+			b.notify(request.Context(), transition)
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, key)
+		}
+		c.probes++
+		probe = true
+	}
+	b.mu.Unlock()
+	b.notify(request.Context(), transition)
+
+	return func(response *http.Response, err error) {
+		// the caller gave up or another hedged attempt won, the call says nothing about the upstream
+		if err != nil && (request.Context().Err() != nil || hedgeLost(request.Context())) {
+			b.release(key, probe, generation)
+			return
+		}
+		b.record(request.Context(), key, probe, generation, b.config.IsFailure(response, err))
+	}, nil
+}
+
+func (b *circuitBreakers) record(ctx context.Context, key string, probe bool, generation uint64, failed bool) {
+	b.mu.Lock()
+	c := b.circuit(key)
+	var transition *circuitTransition
+
+	switch {
+	case probe:
+		if c.generation != generation {
+			break
+		}
+		c.probes--
+		if failed {
+			transition = b.setState(key, c, CircuitOpen)
+		} else if c.passed++; c.passed >= b.config.HalfOpenProbes {
+			transition = b.setState(key, c, CircuitClosed)
+		}
+	case c.state == CircuitClosed:
+		bucket := c.bucket(b.now(), b.config.Window)
+		bucket.total++
+		if failed {
+			bucket.failures++
+		}
+		if total, failures := c.count(b.now(), b.config.Window); total >= b.config.MinRequests &&
+			float64(failures)/float64(total) >= b.config.FailureRate {
+			transition = b.setState(key, c, CircuitOpen)
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(ctx, transition)
+}
+
+// release frees the probe slot of a call which isn't recorded
+func (b *circuitBreakers) release(key string, probe bool, generation uint64) {
+	if !probe {
+		return
+	}
+
+	b.mu.Lock()
+	if c := b.circuit(key); c.generation == generation {
+		c.probes--
+	}
+	b.mu.Unlock()
+}
+
+func (b *circuitBreakers) circuit(key string) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// setState must be called with the lock held, the returned transition is notified after unlock
+func (b *circuitBreakers) setState(key string, c *circuit, state CircuitState) *circuitTransition {
+	transition := &circuitTransition{key: key, from: c.state, to: state}
+
+	c.state = state
+	c.generation++
+	c.probes, c.passed = 0, 0
+	switch state {
+	case CircuitOpen:
+		c.openedAt = b.now()
+	case CircuitClosed:
+		c.buckets = [circuitBuckets]circuitBucket{}
+	}
+	return transition
+}
+
+func (b *circuitBreakers) notify(ctx context.Context, transition *circuitTransition) {
+	if transition == nil {
+		return
+	}
+
+	if b.logger != nil {
+		b.logger.Warnf(ctx, "circuit %s changed from %s to %s", transition.key, transition.from, transition.to)
+	}
+	if b.config.OnStateChange != nil {
+		b.config.OnStateChange(transition.key, transition.from, transition.to)
+	}
+}
+
+func (b *circuitBreakers) state(key string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[key]; ok {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+func (c *circuit) bucket(now time.Time, window time.Duration) *circuitBucket {
+	width := int64(window) / circuitBuckets
+	start := now.UnixNano() / width * width
+	bucket := &c.buckets[(start/width)%circuitBuckets]
+	if bucket.start != start {
+		*bucket = circuitBucket{start: start}
+	}
+	return bucket
+}
+
+func (c *circuit) count(now time.Time, window time.Duration) (total int, failures int) {
+	oldest := now.UnixNano() - int64(window)
+	for _, bucket := range c.buckets {
+		if bucket.start > oldest {
+			total += bucket.total
+			failures += bucket.failures
+		}
+	}
+	return total, failures
+}
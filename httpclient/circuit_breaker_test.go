@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type transitionRecorder struct {
+	transitions []string
+}
+
+func (r *transitionRecorder) record(key string, from, to CircuitState) {
+	r.transitions = append(r.transitions, key+":"+from.String()+">"+to.String())
+}
+
+func newTestBreakers(config CircuitBreakerConfig) (*circuitBreakers, *time.Time, *transitionRecorder) {
+	recorder := &transitionRecorder{}
+	config.OnStateChange = recorder.record
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	breakers := newCircuitBreakers(&config, logtest.New())
+	breakers.now = func() time.Time { return now }
+	return breakers, &now, recorder
+}
+
+func callBreaker(t *testing.T, b *circuitBreakers, status int) error {
+	req := httptest.NewRequest(http.MethodGet, "http://partner.example.com/orders", nil)
+	done, err := b.allow(req)
+	if err != nil {
+		return err
+	}
+	done(&http.Response{StatusCode: status}, nil)
+	return nil
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	breakers, now, recorder := newTestBreakers(CircuitBreakerConfig{MinRequests: 4, FailureRate: 0.5, OpenTimeout: time.Minute})
+	key := "partner.example.com"
+
+	assert.Nil(t, callBreaker(t, breakers, http.StatusOK))
+	assert.Nil(t, callBreaker(t, breakers, http.StatusOK))
+	assert.Nil(t, callBreaker(t, breakers, http.StatusInternalServerError))
+	assert.Equal(t, CircuitClosed, breakers.state(key))
+	assert.Nil(t, callBreaker(t, breakers, http.StatusBadGateway))
+	assert.Equal(t, CircuitOpen, breakers.state(key))
+
+	err := callBreaker(t, breakers, http.StatusOK)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.EqualError(t, err, "httpclient: circuit open: partner.example.com")
+
+	// one probe at a time once the open timeout passed
+	*now = now.Add(time.Minute)
+	req := httptest.NewRequest(http.MethodGet, "http://partner.example.com/orders", nil)
+	done, err := breakers.allow(req)
+	assert.Nil(t, err)
+	assert.Equal(t, CircuitHalfOpen, breakers.state(key))
+	_, err = breakers.allow(req)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+	done(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil)
+	assert.Equal(t, CircuitOpen, breakers.state(key))
+
+	*now = now.Add(time.Minute)
+	assert.Nil(t, callBreaker(t, breakers, http.StatusOK))
+	assert.Equal(t, CircuitClosed, breakers.state(key))
+
+	assert.Equal(t, []string{
+		key + ":closed>open",
+		key + ":open>half-open",
+		key + ":half-open>open",
+		key + ":open>half-open",
+		key + ":half-open>closed",
+	}, recorder.transitions)
+}
+
+func TestCircuitBreaker_Window(t *testing.T) {
+	breakers, now, _ := newTestBreakers(CircuitBreakerConfig{MinRequests: 2, Window: 10 * time.Second})
+
+	assert.Nil(t, callBreaker(t, breakers, http.StatusInternalServerError))
+	*now = now.Add(11 * time.Second)
+	assert.Nil(t, callBreaker(t, breakers, http.StatusInternalServerError))
+	assert.Equal(t, CircuitClosed, breakers.state("partner.example.com"))
+
+	*now = now.Add(5 * time.Second)
+	assert.Nil(t, callBreaker(t, breakers, http.StatusInternalServerError))
+	assert.Equal(t, CircuitOpen, breakers.state("partner.example.com"))
+}
+
+func TestCircuitBreaker_LateProbeIgnored(t *testing.T) {
+	breakers, now, _ := newTestBreakers(CircuitBreakerConfig{MinRequests: 1, OpenTimeout: time.Second, HalfOpenProbes: 2})
+	key := "partner.example.com"
+	assert.Nil(t, callBreaker(t, breakers, http.StatusInternalServerError))
+
+	*now = now.Add(time.Second)
+	req := httptest.NewRequest(http.MethodGet, "http://partner.example.com/orders", nil)
+	late, _ := breakers.allow(req)
+	failing, _ := breakers.allow(req)
+	failing(nil, errors.New("connection reset"))
+	assert.Equal(t, CircuitOpen, breakers.state(key))
+
+	*now = now.Add(time.Second)
+	assert.Nil(t, callBreaker(t, breakers, http.StatusOK))
+	late(&http.Response{StatusCode: http.StatusOK}, nil)
+	assert.Equal(t, CircuitHalfOpen, breakers.state(key))
+}
+
+func TestCircuitBreaker_CallerCancellationIgnored(t *testing.T) {
+	breakers, now, _ := newTestBreakers(CircuitBreakerConfig{MinRequests: 1, OpenTimeout: time.Second})
+	key := "partner.example.com"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "http://partner.example.com/orders", nil).WithContext(ctx)
+	done, _ := breakers.allow(req)
+	done(nil, context.Canceled)
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	done, _ = breakers.allow(req.WithContext(expired))
+	done(nil, context.DeadlineExceeded)
+	assert.Equal(t, CircuitClosed, breakers.state(key))
+
+	// a cancelled probe frees its slot for the next one
+	assert.Nil(t, callBreaker(t, breakers, http.StatusInternalServerError))
+	*now = now.Add(time.Second)
+	done, err := breakers.allow(req)
+	assert.Nil(t, err)
+	done(nil, context.Canceled)
+	assert.Nil(t, callBreaker(t, breakers, http.StatusOK))
+	assert.Equal(t, CircuitClosed, breakers.state(key))
+
+	// the default IsFailure doesn't count a cancellation the caller's context doesn't know about
+	done, _ = breakers.allow(httptest.NewRequest(http.MethodGet, "http://partner.example.com/orders", nil))
+	done(nil, context.Canceled)
+	assert.Equal(t, CircuitClosed, breakers.state(key))
+}
+
+func TestCircuitBreaker_Doer(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var opened int32
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptClient: server.Client(),
+		OptLogger: logger,
+		OptCircuitBreaker: &CircuitBreakerConfig{
+			MinRequests: 3,
+			OnStateChange: func(key string, from, to CircuitState) {
+				if to == CircuitOpen {
+					atomic.AddInt32(&opened, 1)
+				}
+			},
+		},
+	})
+
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/orders", nil)
+		_, err := doer.DoRawResponse(req)
+		if i < 3 {
+			assert.Nil(t, err)
+		} else {
+			assert.True(t, errors.Is(err, ErrCircuitOpen))
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/orders", nil)
+	_, err := doer.DoRawResponseWithoutLogging(req)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&opened))
+	logger.AssertLogged(t, logrus.WarnLevel, "changed from closed to open", nil)
+}
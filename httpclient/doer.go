@@ -1,17 +1,16 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/muhammad-fakhri/go-libs/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -31,6 +30,12 @@ type httpDoer struct {
 	client    *http.Client
 	logger    log.SLogger
 	logConfig *LogConfig
+	retry     *RetryPolicy
+	breakers  *circuitBreakers
+	metrics   Metrics
+	timeouts  *TimeoutPolicy
+	auth      AuthProvider
+	cache     *responseCache
 }
 
 func NewHttpDo(timeout time.Duration, optionalLogger ...log.SLogger) HttpDoer {
@@ -70,6 +75,15 @@ type ClientParam struct {
 	OptLogConf *LogConfig    //if undefined, will log all message parts
 	OptLogger  log.SLogger   //if undefined, will create internal logger instance
 	OptTimeout time.Duration //if undefined, will use default value 5 s
+	OptRetry   *RetryPolicy  //if undefined, will not retry
+	//if undefined, will not use circuit breaker
+	OptCircuitBreaker *CircuitBreakerConfig
+	OptMetrics        Metrics //if undefined, will not record metrics
+	//if undefined, http.Client.Timeout of optTimeout covers the whole call,
+	//otherwise the default httpclient has no timeout and every attempt times out on its own
+	OptTimeoutPolicy *TimeoutPolicy
+	OptAuth          AuthProvider //if undefined, requests are sent as is
+	OptCache         *CacheConfig //if undefined, responses are not cached
 }
 
 func (p *ClientParam) GetTimeout() time.Duration {
@@ -81,6 +95,10 @@ func (p *ClientParam) GetTimeout() time.Duration {
 }
 
 func (p *ClientParam) GetClient() *http.Client {
+	if p.OptClient == nil && p.OptTimeoutPolicy != nil {
+		return &http.Client{}
+	}
+
 	if p.OptClient == nil {
 		return &http.Client{
 			Timeout: p.GetTimeout(),
@@ -111,7 +129,18 @@ func NewHttpDoWithParam(param *ClientParam) HttpDoer {
 	logConfig := param.GetLogConfig()
 	logger := param.GetLogger()
 
-	return newDoerWithLogConfig(client, logger, logConfig)
+	doer := &httpDoer{
+		client:    client,
+		logger:    logger,
+		logConfig: logConfig,
+		retry:     newRetryPolicy(param.OptRetry),
+		breakers:  newCircuitBreakers(param.OptCircuitBreaker, logger),
+		metrics:   param.OptMetrics,
+		timeouts:  newTimeoutPolicy(param.OptTimeoutPolicy, param.GetTimeout()),
+		auth:      param.OptAuth,
+		cache:     newResponseCache(param.OptCache, param.OptAuth != nil, logConfig, logger),
+	}
+	return doer
 }
 
 func newDoer(client *http.Client, logger log.SLogger) HttpDoer {
@@ -159,100 +188,209 @@ func (d *httpDoer) DoV2(req *http.Request) ([]byte, int, error) {
 	return respBytes, resp.StatusCode, err
 }
 
+// DoRawResponse returns the response with unread body, a stream response is logged once its body is read to EOF or closed
 func (d *httpDoer) DoRawResponse(req *http.Request) (resp *http.Response, err error) {
 	resp, err = d.doApiCallAndLogging(req)
 	return
 }
 
 func (d *httpDoer) DoRawResponseWithoutLogging(request *http.Request) (resp *HTTPCallInfo, err error) {
+	resp, _, err = d.send(request)
+	return
+}
+
+// send sends request once through the circuit breaker, authenticated, with the attempt timeout, a client span, timing and metrics.
+// A fresh cached response is returned without sending, and without metrics.
+// The error is only set when the circuit is open or the request can't be authenticated, errors of the call are in HTTPCallInfo.
+func (d *httpDoer) send(request *http.Request) (*HTTPCallInfo, trace.Span, error) {
+	cached := d.cache.lookup(request)
+	if cached != nil && cached.fresh {
+		return &HTTPCallInfo{
+			Request:          request,
+			Response:         d.cache.response(request, cached.entry),
+			RequestTimestamp: time.Now(),
+			Cache:            CacheHit,
+		}, trace.SpanFromContext(request.Context()), nil
+	}
+
+	// only the sent copy carries the credentials, HTTPCallInfo.Request is logged
+	authenticated, err := authenticate(d.auth, request)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	done, err := d.breakers.allow(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	host, route := request.URL.Host, RouteFromContext(request.Context())
+	if d.metrics != nil {
+		d.metrics.CallStarted(host, route, request.Method)
+	}
+
+	attempt, cancel := d.timeouts.withTimeout(cached.conditional(authenticated))
+	outgoing, span := startSpan(attempt)
+	outgoing, timing := withTiming(outgoing)
 	requestStartTime := time.Now()
-	response, httpErr := d.client.Do(request)
+	response, httpErr := d.client.Do(outgoing)
 	requestDuration := time.Since(requestStartTime)
+	endSpan(span, response, httpErr)
+	done(response, httpErr)
+	cancelOnClose(response, cancel)
 
-	resp = &HTTPCallInfo{
+	call := &HTTPCallInfo{
 		Request:          request,
 		Response:         response,
 		Duration:         requestDuration,
 		RequestTimestamp: requestStartTime,
 		HttpError:        httpErr,
+		Timing:           timing.timing(requestStartTime),
+	}
+
+	if d.metrics != nil {
+		metrics := CallMetrics{
+			Host:      host,
+			Route:     route,
+			Method:    request.Method,
+			ErrorType: errorType(httpErr),
+			Duration:  requestDuration,
+			Timing:    call.Timing,
+		}
+		if response != nil {
+			metrics.StatusCode = response.StatusCode
+		}
+		d.metrics.CallFinished(metrics)
 	}
 
-	return resp, nil
+	call.Response, call.Cache = d.cache.update(request, cached, response)
+	return call, span, nil
 }
 
 func (d *httpDoer) doApiCallAndLogging(request *http.Request) (*http.Response, error) {
 	getRequestBody := request.GetBody
-	requestStartTime := time.Now()
-	response, httpErr := d.client.Do(request)
-	requestDuration := time.Since(requestStartTime)
+	retry := d.retry.canRetry(request)
+	requestBody := d.captureRequestBody(request)
 
-	//Re-attach Request Body after read by http.client.do()
-	if request.Body != nil {
-		var err error
-		request.Body, err = getRequestBody()
+	var logContext context.Context
+	for attempt := 1; ; attempt++ {
+		call, span, err := d.send(request)
 		if err != nil {
 			return nil, err
 		}
-	}
+		response, httpErr := call.Response, call.HttpError
+
+		//Re-attach Request Body after read by http.client.do()
+		if request.Body != nil && getRequestBody != nil {
+			request.Body, err = getRequestBody()
+			if err != nil {
+				return nil, err
+			}
+		}
 
-	context, err := d.buildContextFromRequest(request)
-	if err != nil {
-		return nil, err
-	}
+		if logContext == nil {
+			logContext = d.buildContextFromRequest(request, requestBody)
+		}
+		d.logApiCallOnBodyDone(trace.ContextWithSpan(logContext, span), call, requestBody, attempt)
 
-	err = d.logApiCall(context, request, response, requestDuration, requestStartTime, httpErr)
-	if err != nil {
-		return nil, err
+		var delay time.Duration
+		if retry && request.Context().Err() == nil {
+			delay, retry = d.retry.backoff(attempt, response, httpErr)
+		} else {
+			retry = false
+		}
+
+		if !retry {
+			if httpErr != nil {
+				return nil, httpErr
+			}
+			return response, nil
+		}
+
+		if response != nil {
+			response.Body.Close()
+		}
+		if err := sleep(request.Context(), delay); err != nil {
+			return nil, err
+		}
 	}
-	return response, nil
 }
 
-func (d *httpDoer) buildContextFromRequest(request *http.Request) (context.Context, error) {
-	var country string
-	if country = request.Header.Get("X-Country"); country == "" {
-		country = request.Header.Get("X-Tenant")
+// buildContextFromRequest returns request context with the log context data of the caller, e.g. set by httpmiddleware,
+// missing country and context id are taken from X-Country/X-Tenant header and request_id field of the logged body or a new UUID
+func (d *httpDoer) buildContextFromRequest(request *http.Request, requestBody *bodyCapture) context.Context {
+	ctx := request.Context()
+	data, _ := ctx.Value(log.ContextDataMapKey).(map[string]string)
+	missing := make(map[string]string)
+
+	if data[log.ContextCountryKey] == "" {
+		country := request.Header.Get("X-Country")
+		if country == "" {
+			country = request.Header.Get("X-Tenant")
+		}
+		missing[log.ContextCountryKey] = country
 	}
 
-	requestID := ""
-	requestBody, err := getRequestBodyJSON(request)
-	if err != nil {
-		return request.Context(), err
+	if data[log.ContextIdKey] == "" {
+		var body map[string]interface{}
+		if requestBody != nil && !requestBody.truncated {
+			json.Unmarshal(requestBody.buf.Bytes(), &body)
+		}
+		if value, exists := body["request_id"].(string); exists {
+			missing[log.ContextIdKey] = value
+		} else {
+			missing[log.ContextIdKey] = uuid.New().String()
+		}
 	}
 
-	var body map[string]interface{}
-	json.Unmarshal([]byte(requestBody), &body)
-	if value, exists := body["request_id"]; exists {
-		requestID = value.(string)
-	} else {
-		requestID = uuid.New().String()
+	if len(missing) == 0 {
+		return ctx
 	}
-
-	return d.logger.BuildContextDataAndSetValue(country, requestID), nil
+	return log.WithContextData(ctx, missing)
 }
 
-func (d *httpDoer) logApiCall(context context.Context, request *http.Request, response *http.Response, duration time.Duration, requestTimestamp time.Time, httpError error) error {
-	data := &log.RequestResponse{}
-
-	requestBody, err := getRequestBodyJSON(request)
-	if err != nil {
-		return err
+// logApiCallOnBodyDone logs failed calls right away, other calls when the response body is captured, see logOnBodyDone.
+// Hedged attempts cancelled because another attempt won are not logged, cancelLosers logs them.
+func (d *httpDoer) logApiCallOnBodyDone(context context.Context, call *HTTPCallInfo, requestBody *bodyCapture, attempt int) {
+	if call.HttpError != nil {
+		if hedgeLost(call.Request.Context()) {
+			return
+		}
+		d.logApiCall(context, call, requestBody, nil, attempt)
+		return
 	}
 
+	d.logOnBodyDone(call.Response, func(responseBody *bodyCapture) {
+		d.logApiCall(context, call, requestBody, responseBody, attempt)
+	})
+}
+
+func (d *httpDoer) logApiCall(context context.Context, call *HTTPCallInfo, requestBody *bodyCapture, responseBody *bodyCapture, attempt int) {
+	request, response := call.Request, call.Response
+	data := &log.RequestResponse{DataMap: map[string]interface{}{FieldAttempt: attempt}}
+
 	data.Type = valueLogTypeEgressHttp
 	data.URLPath = fmt.Sprintf("%s %s", request.Method, request.URL.String())
-	data.RequestTimestamp = requestTimestamp
-	data.DurationMs = duration.Milliseconds()
+	data.RequestTimestamp = call.RequestTimestamp
+	data.DurationMs = call.Duration.Milliseconds()
+	for field, value := range call.Timing.dataMap() {
+		data.DataMap[field] = value
+	}
+	if call.Cache != "" {
+		data.DataMap[FieldCache] = string(call.Cache)
+	}
 
-	if httpError != nil {
-		data.Status = statusGeneralError
-		d.logger.ErrorMap(context, data.ToDataMap(), httpError.Error())
-		return httpError
+	if requestBody != nil && !requestBody.partial {
+		data.DataMap[FieldReqSize] = requestBody.size
+	} else if request.ContentLength > 0 {
+		data.DataMap[FieldReqSize] = request.ContentLength
 	}
 
-	responseBody, err := getResponseBodyJSON(response)
-	if err != nil {
-		return err
+	if call.HttpError != nil {
+		data.Status = statusGeneralError
+		data.DataMap[FieldErrorType] = errorType(call.HttpError)
+		d.logger.ErrorMap(context, data.ToDataMap(), call.HttpError.Error())
+		return
 	}
 
 	if response != nil {
@@ -261,58 +399,38 @@ func (d *httpDoer) logApiCall(context context.Context, request *http.Request, re
 		if d.logConfig.LogResponseHeader() {
 			header := response.Header.Clone()
 			header.Del("Authorization")
-			data.ResponseHeader = response.Header
+			data.ResponseHeader = header
 		}
 
-		if d.logConfig.LogResponseBody() {
-			data.ResponseBody = responseBody
-		} else {
+		if responseBody != nil && !responseBody.partial {
+			data.DataMap[FieldResponseSize] = responseBody.size
+		} else if response.ContentLength >= 0 {
+			data.DataMap[FieldResponseSize] = response.ContentLength
+		}
+
+		if !d.logConfig.LogResponseBody() || d.logConfig.isStream(response) {
 			data.ResponseBody = wipedMessage
+		} else if responseBody != nil {
+			data.ResponseBody = responseBody.String()
+		} else {
+			data.ResponseBody = "null"
 		}
 	}
 
 	if d.logConfig.LogRequestHeader() {
 		header := request.Header.Clone()
 		header.Del("Authorization")
-		data.RequestHeader = request.Header
+		data.RequestHeader = header
 	}
 
-	if d.logConfig.LogRequestBody() {
-		data.RequestBody = requestBody
-	} else {
+	if !d.logConfig.LogRequestBody() {
 		data.RequestBody = wipedMessage
+	} else if requestBody != nil {
+		data.RequestBody = requestBody.String()
+	} else {
+		data.RequestBody = "null"
 	}
 
+	d.logConfig.Redactor.RedactRequestResponse(data)
 	d.logger.LogRequestResponse(context, data)
-	return nil
-}
-
-func getRequestBodyJSON(request *http.Request) (string, error) {
-	if request.Body == nil {
-		return "null", nil
-	}
-
-	requestBodyBytes, err := getBodyBytes(&request.Body)
-	if err != nil {
-		return "null", err
-	}
-	return string(requestBodyBytes), nil
-}
-
-func getResponseBodyJSON(response *http.Response) (string, error) {
-	if response == nil || response.Body == nil || response.Body == http.NoBody {
-		return "null", nil
-	}
-
-	responseBodyBytes, err := getBodyBytes(&response.Body)
-	if err != nil {
-		return "null", err
-	}
-	return string(responseBodyBytes), nil
-}
-
-func getBodyBytes(body *io.ReadCloser) ([]byte, error) {
-	responseBodyBytes, err := ioutil.ReadAll(*body)
-	*body = ioutil.NopCloser(bytes.NewBuffer(responseBodyBytes))
-	return responseBodyBytes, err
 }
@@ -2,6 +2,7 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,8 +12,10 @@ import (
 	"time"
 
 	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -43,6 +46,11 @@ func getHandlers() *http.ServeMux {
 		fmt.Fprintln(writer, ReturnXml)
 	})
 
+	mux.HandleFunc("/return/200/traceparent", func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+		fmt.Fprint(writer, request.Header.Get("traceparent"))
+	})
+
 	mux.HandleFunc("/return/204", func(writer http.ResponseWriter, request *http.Request) {
 		writer.WriteHeader(http.StatusNoContent)
 	})
@@ -593,9 +601,10 @@ func TestHttpDoer_DoRawResponse_WithPostMethod_ReturnErrorRequestTimeout(t *test
 	payloadBytes, _ := json.Marshal(payload)
 
 	logger, hook := log.NewSLoggerWithTestHook("httpClient")
-	client := getMockServer().Client()
+	// copy the shared client of the mock server, other tests must not inherit the timeout
+	client := *getMockServer().Client()
 	client.Timeout = 1 * time.Millisecond
-	httpClient := newDoer(client, logger)
+	httpClient := newDoer(&client, logger)
 	req, _ := http.NewRequest(http.MethodPost, apiUrl, bytes.NewReader(payloadBytes))
 
 	_, err := httpClient.DoRawResponse(req)
@@ -662,3 +671,72 @@ func TestHttpDoer_WithLogConfig_DoRawResponse_WithGetMethod_WithHeaderXCountry_R
 	assert.Nil(t, hook.LastEntry().Data[log.FieldReqHeader])
 	assert.NotNil(t, hook.LastEntry().Data[log.FieldResponseHeader])
 }
+
+func TestHttpDoer_Do_RedactsSensitiveData(t *testing.T) {
+	apiUrl := getMockServer().URL + "/return/200/json"
+	logger, hook := log.NewSLoggerWithTestHook("httpClient")
+	httpClient := newDoer(getMockServer().Client(), logger)
+	req, _ := http.NewRequest(http.MethodPost, apiUrl, bytes.NewReader([]byte(`{"email":"john@example.com","password":"p4ss"}`)))
+	req.Header.Add("Authorization", "Bearer abcdefghijkl")
+	req.Header.Add("X-Api-Key", "abcdefghijkl")
+
+	_, err := httpClient.Do(req)
+	assert.Nil(t, err)
+
+	logMessage := extractLogMessage(hook.LastEntry().Data)
+	assert.Empty(t, logMessage.ReqHeader.Get("Authorization"))
+	assert.Equal(t, "[REDACTED]", logMessage.ReqHeader.Get("X-Api-Key"))
+	assert.Equal(t, `{"email":"************.com","password":"[REDACTED]"}`, logMessage.ReqBody)
+	// request sent to the server is left untouched
+	assert.Equal(t, "abcdefghijkl", req.Header.Get("X-Api-Key"))
+}
+
+func TestHttpDoer_Do_PropagatesTraceparent(t *testing.T) {
+	apiUrl := getMockServer().URL + "/return/200/traceparent"
+	logger, hook := log.NewSLoggerWithTestHook("httpClient")
+	httpClient := newDoer(getMockServer().Client(), logger)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl, nil)
+
+	resp, err := httpClient.Do(req)
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(resp), "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hook.LastEntry().Data[log.FieldTraceID])
+	// caller request is not modified
+	assert.Empty(t, req.Header.Get("traceparent"))
+}
+
+func TestHttpDoer_Do_LogsEgressFields(t *testing.T) {
+	apiUrl := getMockServer().URL + "/return/200/json"
+	logger := logtest.New()
+	httpClient := newDoer(getMockServer().Client(), logger)
+	req, _ := http.NewRequest(http.MethodGet, apiUrl, nil)
+	req.Header.Add("X-Country", "ID")
+
+	_, err := httpClient.Do(req)
+	assert.Nil(t, err)
+
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldType:         valueLogTypeEgressHttp,
+		log.FieldURL:          "GET " + apiUrl,
+		log.FieldStatus:       http.StatusOK,
+		log.ContextCountryKey: "ID",
+	})
+
+	failedReq, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:0/unreachable", nil)
+	_, err = httpClient.Do(failedReq)
+	assert.NotNil(t, err)
+
+	logger.AssertLogged(t, logrus.ErrorLevel, "connect", map[string]interface{}{
+		log.FieldType:   valueLogTypeEgressHttp,
+		log.FieldStatus: statusGeneralError,
+	})
+}
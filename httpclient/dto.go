@@ -12,4 +12,6 @@ type HTTPCallInfo struct {
 	Duration         time.Duration
 	RequestTimestamp time.Time
 	HttpError        error
+	Timing           Timing
+	Cache            CacheStatus // how the response was served by ClientParam.OptCache
 }
@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultHedgePercentile = 0.95
+	defaultHedgeMinDelay   = 10 * time.Millisecond
+	defaultHedgeMaxHedges  = 1
+
+	// latencySamples is the window of the latency percentile, hedging waits for MinDelay until it is half full
+	latencySamples = 200
+)
+
+// HedgePolicy sends a copy of a slow request to another instance and takes the first success, losing attempts are cancelled.
+// Only requests which are safe to send again are hedged, see LoadBalancerConfig.Attempts.
+type HedgePolicy struct {
+	Delay      time.Duration // wait before sending a copy, default value: Percentile of the recent latencies of the service
+	Percentile float64       // default value: 0.95
+	MinDelay   time.Duration // lower bound of the percentile delay, default value: 10ms
+	MaxHedges  int           // copies sent besides the first request, default value: 1
+}
+
+func newHedgePolicy(p *HedgePolicy) *HedgePolicy {
+	if p == nil {
+		return nil
+	}
+
+	policy := *p
+	if policy.Percentile <= 0 || policy.Percentile >= 1 {
+		policy.Percentile = defaultHedgePercentile
+	}
+	if policy.MinDelay <= 0 {
+		policy.MinDelay = defaultHedgeMinDelay
+	}
+	if policy.MaxHedges <= 0 {
+		policy.MaxHedges = defaultHedgeMaxHedges
+	}
+	return &policy
+}
+
+// latencyWindow keeps the latencies of the last latencySamples successful calls
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) observe(latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < latencySamples {
+		w.samples = append(w.samples, latency)
+		return
+	}
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % latencySamples
+}
+
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	w.mu.Unlock()
+
+	if len(sorted) < latencySamples/2 {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(p*float64(len(sorted)-1))], true
+}
+
+func (b *loadBalancer) hedgeDelay() time.Duration {
+	hedge := b.config.Hedge
+	if hedge.Delay > 0 {
+		return hedge.Delay
+	}
+	if delay, ok := b.latencies.percentile(hedge.Percentile); ok && delay > hedge.MinDelay {
+		return delay
+	}
+	return hedge.MinDelay
+}
+
+// hedgeLostKey marks the context of a hedged attempt, set to 1 when it is cancelled because another attempt won
+type hedgeLostKey struct{}
+
+// hedgeLost reports whether ctx is of an attempt cancelled by cancelLosers, its error is not a failure of the upstream
+func hedgeLost(ctx context.Context) bool {
+	lost, ok := ctx.Value(hedgeLostKey{}).(*int32)
+	return ok && atomic.LoadInt32(lost) == 1
+}
+
+type hedgeAttempt struct {
+	number   int
+	instance *instance
+	cancel   context.CancelFunc
+	lost     *int32
+	started  time.Time
+	response *http.Response
+	err      error
+}
+
+// doHedged sends request, and a copy to another instance every hedge delay or after a failure,
+// until one succeeds or MaxHedges copies failed. The losing attempts are cancelled.
+func (b *loadBalancer) doHedged(request *http.Request, send func(request *http.Request) (*http.Response, error)) (*http.Response, error) {
+	results := make(chan *hedgeAttempt, b.config.Hedge.MaxHedges+1)
+	tried := make(map[*instance]bool)
+	pending := make(map[*hedgeAttempt]bool)
+	attempts := 0
+
+	start := func() bool {
+		picked := b.pick(tried)
+		if picked == nil {
+			return false
+		}
+		tried[picked] = true
+
+		lost := new(int32)
+		ctx, cancel := context.WithCancel(context.WithValue(request.Context(), hedgeLostKey{}, lost))
+		outgoing, err := b.outgoing(request.WithContext(ctx), picked, attempts+1)
+		if err != nil {
+			cancel()
+			b.release(picked)
+			return false
+		}
+
+		attempts++
+		a := &hedgeAttempt{number: attempts, instance: picked, cancel: cancel, lost: lost, started: time.Now()}
+		pending[a] = true
+		go func() {
+			a.response, a.err = send(outgoing)
+			results <- a
+		}()
+		return true
+	}
+
+	if !start() {
+		return nil, b.noInstance()
+	}
+
+	timer := time.NewTimer(b.hedgeDelay())
+	defer timer.Stop()
+
+	var last *hedgeAttempt
+	for len(pending) > 0 {
+		select {
+		case <-timer.C:
+			if attempts <= b.config.Hedge.MaxHedges && start() {
+				timer.Reset(b.hedgeDelay())
+			}
+
+		case a := <-results:
+			delete(pending, a)
+			failed := b.config.IsFailure(a.response, a.err)
+			// an open circuit of the instance doesn't tell more about its health, it is only skipped
+			b.record(request.Context(), a.instance, failed && !errors.Is(a.err, ErrCircuitOpen))
+
+			if !failed {
+				b.latencies.observe(time.Since(a.started))
+				b.cancelLosers(request.Context(), a, pending, results)
+				return b.finish(a), nil
+			}
+
+			if last != nil {
+				b.discard(last)
+			}
+			last = a
+			if len(pending) == 0 && attempts <= b.config.Hedge.MaxHedges && request.Context().Err() == nil {
+				start()
+			}
+		}
+	}
+	return b.finish(last), last.err
+}
+
+// finish returns the response of a, its instance is released and its context cancelled when the body is closed
+func (b *loadBalancer) finish(a *hedgeAttempt) *http.Response {
+	release := func() {
+		a.cancel()
+		b.release(a.instance)
+	}
+	if a.response == nil || a.response.Body == nil {
+		release()
+		return a.response
+	}
+	a.response.Body = &releaseBody{ReadCloser: a.response.Body, release: release}
+	return a.response
+}
+
+func (b *loadBalancer) discard(a *hedgeAttempt) {
+	if response := b.finish(a); response != nil && response.Body != nil {
+		response.Body.Close()
+	}
+}
+
+// cancelLosers cancels the attempts still pending after winner, their results are discarded in the background.
+// The attempts are marked lost first, so their cancellation is neither recorded by the circuit breaker nor logged as an error.
+func (b *loadBalancer) cancelLosers(ctx context.Context, winner *hedgeAttempt, pending map[*hedgeAttempt]bool, results chan *hedgeAttempt) {
+	for a := range pending {
+		atomic.StoreInt32(a.lost, 1)
+		a.cancel()
+		b.config.Logger.Infof(ctx, "hedged attempt %d to %s cancelled, attempt %d to %s won",
+			a.number, a.instance.base, winner.number, winner.instance.base)
+	}
+
+	go func(losers int) {
+		for i := 0; i < losers; i++ {
+			b.discard(<-results)
+		}
+	}(len(pending))
+}
@@ -0,0 +1,164 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBalancer_HedgesSlowInstance(t *testing.T) {
+	var cancelled int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+			w.Write([]byte("slow"))
+		case <-r.Context().Done():
+			atomic.AddInt32(&cancelled, 1)
+		}
+	}))
+	defer slow.Close()
+	fast := newCountingServer(http.StatusOK)
+	defer fast.Close()
+
+	doer, logger := newBalancerTestDoer(t, LoadBalancerConfig{
+		Service:  "uuid",
+		Resolver: NewStaticResolver(slow.URL, fast.URL),
+		Hedge:    &HedgePolicy{Delay: 20 * time.Millisecond},
+	})
+
+	started := time.Now()
+	req, _ := http.NewRequest(http.MethodGet, "http://uuid/hedged", nil)
+	body, status, err := doer.DoV2(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "/hedged", string(body))
+	assert.True(t, time.Since(started) < 500*time.Millisecond)
+	logger.AssertLogged(t, logrus.InfoLevel, "hedged attempt 1", nil)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&cancelled) == 1 }, time.Second, 10*time.Millisecond)
+
+	// the winner and the cancelled loser both release their instance
+	assert.Eventually(t, func() bool {
+		balancer := doer.(*loadBalancer)
+		balancer.mu.Lock()
+		defer balancer.mu.Unlock()
+		return balancer.instances[0].outstanding == 0 && balancer.instances[1].outstanding == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLoadBalancer_LostHedgeIsNotAFailure(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	fast := newCountingServer(http.StatusOK)
+	defer fast.Close()
+
+	egress := logtest.New()
+	inner := NewHttpDoWithParam(&ClientParam{
+		OptClient:         &http.Client{},
+		OptLogger:         egress,
+		OptCircuitBreaker: &CircuitBreakerConfig{MinRequests: 1},
+	})
+	doer, err := NewLoadBalancedDoer(inner, LoadBalancerConfig{
+		Service:  "uuid",
+		Resolver: NewStaticResolver(slow.URL, fast.URL),
+		Hedge:    &HedgePolicy{Delay: 20 * time.Millisecond},
+		Logger:   logtest.New(),
+	})
+	assert.Nil(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://uuid/hedged", nil)
+	_, status, err := doer.DoV2(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	// the loser releases its instance once its call returned and was logged
+	assert.Eventually(t, func() bool {
+		balancer := doer.(*loadBalancer)
+		balancer.mu.Lock()
+		defer balancer.mu.Unlock()
+		return balancer.instances[0].outstanding == 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Empty(t, egress.Find(logrus.ErrorLevel, "", nil))
+	slowURL, _ := url.Parse(slow.URL)
+	assert.Equal(t, CircuitClosed, inner.(*httpDoer).breakers.state(slowURL.Host))
+}
+
+func TestLoadBalancer_HedgesAfterFailure(t *testing.T) {
+	failing, healthy := newCountingServer(http.StatusBadGateway), newCountingServer(http.StatusOK)
+	defer failing.Close()
+	defer healthy.Close()
+
+	doer, _ := newBalancerTestDoer(t, LoadBalancerConfig{
+		Service:  "uuid",
+		Resolver: NewStaticResolver(failing.URL, healthy.URL),
+		Hedge:    &HedgePolicy{Delay: time.Minute},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://uuid/", nil)
+	_, status, err := doer.DoV2(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failing.hits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&healthy.hits))
+}
+
+func TestLoadBalancer_HedgedOpenCircuitIsNotAFailure(t *testing.T) {
+	failing, healthy := newCountingServer(http.StatusBadGateway), newCountingServer(http.StatusOK)
+	defer failing.Close()
+	defer healthy.Close()
+
+	inner := NewHttpDoWithParam(&ClientParam{
+		OptClient:         &http.Client{},
+		OptLogger:         logtest.New(),
+		OptCircuitBreaker: &CircuitBreakerConfig{MinRequests: 1},
+	})
+	doer, err := NewLoadBalancedDoer(inner, LoadBalancerConfig{
+		Service:  "uuid",
+		Resolver: NewStaticResolver(failing.URL, healthy.URL),
+		Hedge:    &HedgePolicy{Delay: time.Minute},
+		Logger:   logtest.New(),
+	})
+	assert.Nil(t, err)
+
+	for i := 0; i < 2*defaultBalancerEjectAfter; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://uuid/", nil)
+		_, status, err := doer.DoV2(req)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	}
+
+	// attempts skipped by the open circuit don't eject the instance
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failing.hits))
+	balancer := doer.(*loadBalancer)
+	balancer.mu.Lock()
+	defer balancer.mu.Unlock()
+	assert.True(t, balancer.instances[0].ejectedUntil.IsZero())
+}
+
+func TestLatencyWindow_Percentile(t *testing.T) {
+	window := &latencyWindow{}
+	_, ok := window.percentile(0.95)
+	assert.False(t, ok)
+
+	for i := 1; i <= 2*latencySamples; i++ {
+		window.observe(time.Duration(i) * time.Millisecond)
+	}
+	p95, ok := window.percentile(0.95)
+	assert.True(t, ok)
+	// only the last 200 latencies, 201ms to 400ms, are kept
+	assert.Equal(t, 390*time.Millisecond, p95)
+}
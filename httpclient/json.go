@@ -0,0 +1,222 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const contentTypeJSON = "application/json"
+
+// RequestOption configures a request of the JSON helpers, e.g. GetJSON and PostJSON
+type RequestOption func(c *requestConfig)
+
+type requestConfig struct {
+	baseURL     string
+	pathParams  map[string]string
+	query       url.Values
+	header      http.Header
+	decodeError func(body []byte) (interface{}, error)
+}
+
+// WithBaseURL prefixes the url of the request, e.g. WithBaseURL("http://uuid.svc") with url "/uui/cluster"
+func WithBaseURL(baseURL string) RequestOption {
+	return func(c *requestConfig) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithPathParam replaces {name} in the url with the path escaped value
+func WithPathParam(name, value string) RequestOption {
+	return func(c *requestConfig) {
+		if c.pathParams == nil {
+			c.pathParams = make(map[string]string)
+		}
+		c.pathParams[name] = value
+	}
+}
+
+// WithQuery adds query params to the url, params already in the url are kept
+func WithQuery(query url.Values) RequestOption {
+	return func(c *requestConfig) {
+		for key, values := range query {
+			for _, value := range values {
+				c.query.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithHeader sets header of the request, Content-Type and Accept default to application/json
+func WithHeader(key, value string) RequestOption {
+	return func(c *requestConfig) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithErrorBody decodes the body of non 2xx responses into E, see StatusError and ErrorBody
+func WithErrorBody[E any]() RequestOption {
+	return func(c *requestConfig) {
+		c.decodeError = func(body []byte) (interface{}, error) {
+			var value E
+			err := json.Unmarshal(body, &value)
+			return value, err
+		}
+	}
+}
+
+// StatusError is returned by the JSON helpers for non 2xx responses
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+	// Value is the body decoded by WithErrorBody, nil when not given or the body is not valid
+	Value interface{}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("httpclient: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns Value when it is an error, so errors.As finds the decoded error body
+func (e *StatusError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// ErrorBody returns the error body decoded by WithErrorBody[E] from err
+func ErrorBody[E any](err error) (E, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		value, ok := statusErr.Value.(E)
+		return value, ok
+	}
+	var zero E
+	return zero, false
+}
+
+// GetJSON sends GET request to rawURL and decodes the JSON response into Resp
+func GetJSON[Resp any](ctx context.Context, doer HttpDoer, rawURL string, options ...RequestOption) (Resp, error) {
+	return doJSON[Resp](ctx, doer, http.MethodGet, rawURL, nil, options)
+}
+
+// DeleteJSON sends DELETE request to rawURL and decodes the JSON response into Resp
+func DeleteJSON[Resp any](ctx context.Context, doer HttpDoer, rawURL string, options ...RequestOption) (Resp, error) {
+	return doJSON[Resp](ctx, doer, http.MethodDelete, rawURL, nil, options)
+}
+
+// PostJSON sends request encoded as JSON to rawURL and decodes the JSON response into Resp
+func PostJSON[Req, Resp any](ctx context.Context, doer HttpDoer, rawURL string, request Req, options ...RequestOption) (Resp, error) {
+	return DoJSON[Req, Resp](ctx, doer, http.MethodPost, rawURL, request, options...)
+}
+
+// PutJSON sends request encoded as JSON to rawURL and decodes the JSON response into Resp
+func PutJSON[Req, Resp any](ctx context.Context, doer HttpDoer, rawURL string, request Req, options ...RequestOption) (Resp, error) {
+	return DoJSON[Req, Resp](ctx, doer, http.MethodPut, rawURL, request, options...)
+}
+
+// PatchJSON sends request encoded as JSON to rawURL and decodes the JSON response into Resp
+func PatchJSON[Req, Resp any](ctx context.Context, doer HttpDoer, rawURL string, request Req, options ...RequestOption) (Resp, error) {
+	return DoJSON[Req, Resp](ctx, doer, http.MethodPatch, rawURL, request, options...)
+}
+
+// DoJSON sends request encoded as JSON with method to rawURL and decodes the JSON response into Resp.
+// Non 2xx responses return *StatusError, an empty response body returns the zero Resp.
+func DoJSON[Req, Resp any](ctx context.Context, doer HttpDoer, method, rawURL string, request Req, options ...RequestOption) (Resp, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		var zero Resp
+		return zero, fmt.Errorf("httpclient: encode request: %w", err)
+	}
+	return doJSON[Resp](ctx, doer, method, rawURL, body, options)
+}
+
+func doJSON[Resp any](ctx context.Context, doer HttpDoer, method, rawURL string, body []byte, options []RequestOption) (Resp, error) {
+	var response Resp
+
+	c := &requestConfig{query: url.Values{}, header: http.Header{}}
+	c.header.Set("Accept", contentTypeJSON)
+	if body != nil {
+		c.header.Set("Content-Type", contentTypeJSON)
+	}
+	for _, o := range options {
+		o(c)
+	}
+
+	target, err := c.url(rawURL)
+	if err != nil {
+		return response, err
+	}
+
+	if len(c.pathParams) > 0 && RouteFromContext(ctx) == "" {
+		if template, err := url.Parse(rawURL); err == nil {
+			ctx = WithRoute(ctx, template.Path)
+		}
+	}
+
+	var reader io.Reader
+	if body != nil {
+		// bytes.Reader lets http.NewRequest set GetBody, so the request can be retried
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return response, err
+	}
+	for key, values := range c.header {
+		request.Header[key] = values
+	}
+
+	responseBody, statusCode, err := doer.DoV2(request)
+	if err != nil {
+		return response, err
+	}
+
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+		statusErr := &StatusError{StatusCode: statusCode, Body: responseBody}
+		if c.decodeError != nil && len(responseBody) > 0 {
+			if value, err := c.decodeError(responseBody); err == nil {
+				statusErr.Value = value
+			}
+		}
+		return response, statusErr
+	}
+
+	if len(bytes.TrimSpace(responseBody)) == 0 {
+		return response, nil
+	}
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return response, fmt.Errorf("httpclient: decode response: %w", err)
+	}
+	return response, nil
+}
+
+// url joins base url and rawURL, fills the path params and adds the query params
+func (c *requestConfig) url(rawURL string) (string, error) {
+	if c.baseURL != "" {
+		rawURL = strings.TrimSuffix(c.baseURL, "/") + "/" + strings.TrimPrefix(rawURL, "/")
+	}
+	for name, value := range c.pathParams {
+		rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", url.PathEscape(value))
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if len(c.query) > 0 {
+		query := target.Query()
+		for key, values := range c.query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		target.RawQuery = query.Encode()
+	}
+	return target.String(), nil
+}
@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonTestRequest struct {
+	Name string `json:"name"`
+}
+
+type jsonTestResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type jsonTestError struct {
+	Title string `json:"title"`
+}
+
+func (e jsonTestError) Error() string {
+	return e.Title
+}
+
+func TestPostJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/users/a%2Fb", r.URL.EscapedPath())
+		assert.Equal(t, "1", r.URL.Query().Get("page"))
+		assert.Equal(t, "x", r.URL.Query().Get("tag"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "client-1", r.Header.Get("X-Client-Id"))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.JSONEq(t, `{"name":"fakhri"}`, string(body))
+		w.Write([]byte(`{"id":7,"name":"fakhri"}`))
+	}))
+	defer server.Close()
+
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logtest.New()})
+	defaults := []RequestOption{WithBaseURL(server.URL + "/v1/"), WithHeader("X-Client-Id", "client-1")}
+
+	response, err := PostJSON[jsonTestRequest, jsonTestResponse](context.Background(), doer, "/users/{id}?tag=x",
+		jsonTestRequest{Name: "fakhri"},
+		append(defaults, WithPathParam("id", "a/b"), WithQuery(url.Values{"page": {"1"}}))...)
+
+	assert.Nil(t, err)
+	assert.Equal(t, jsonTestResponse{ID: 7, Name: "fakhri"}, response)
+}
+
+func TestGetJSON_EmptyBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logtest.New()})
+
+	response, err := GetJSON[*jsonTestResponse](context.Background(), doer, server.URL)
+	assert.Nil(t, err)
+	assert.Nil(t, response)
+}
+
+func TestDoJSON_ErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(jsonTestError{Title: "invalid name"})
+	}))
+	defer server.Close()
+
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logtest.New()})
+
+	_, err := PutJSON[jsonTestRequest, jsonTestResponse](context.Background(), doer, server.URL, jsonTestRequest{},
+		WithErrorBody[jsonTestError]())
+
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+
+	var apiErr jsonTestError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "invalid name", apiErr.Title)
+
+	body, ok := ErrorBody[jsonTestError](err)
+	assert.True(t, ok)
+	assert.Equal(t, "invalid name", body.Title)
+
+	_, err = DeleteJSON[jsonTestResponse](context.Background(), doer, server.URL)
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Nil(t, statusErr.Value)
+	_, ok = ErrorBody[jsonTestError](err)
+	assert.False(t, ok)
+}
@@ -3,6 +3,8 @@ package httpclient
 import (
 	"net/http"
 	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log"
 )
 
 const (
@@ -10,8 +12,12 @@ const (
 	IncludeLog = false
 
 	wipedMessage = "-"
+
+	defaultMaxBodySize = 64 << 10
 )
 
+var defaultStreamContentTypes = []string{"text/event-stream", "application/octet-stream"}
+
 // LogMessage is a struct to keep the log message easier
 type LogMessage struct {
 	URL            string
@@ -26,6 +32,13 @@ type LogMessage struct {
 
 type LogConfig struct {
 	ExcludeOpt *ExcludeOption
+	Redactor   *log.Redactor // masks headers and bodies before logging, default value: log.DefaultRedactor()
+	// MaxBodySize is the bytes of each body kept for logging, longer bodies are truncated, default value: 64 KiB.
+	// A truncated JSON body has the values of keys named like a field rule of the redactor masked, see log.Redactor.RedactBody.
+	MaxBodySize int
+	// StreamContentTypes are passed through without capturing the body, only its size is logged,
+	// default value: text/event-stream, application/octet-stream
+	StreamContentTypes []string
 }
 
 type ExcludeOption struct {
@@ -37,7 +50,10 @@ type ExcludeOption struct {
 
 func defaultLogConfig() *LogConfig {
 	return &LogConfig{
-		ExcludeOpt: &ExcludeOption{},
+		ExcludeOpt:         &ExcludeOption{},
+		Redactor:           log.DefaultRedactor(),
+		MaxBodySize:        defaultMaxBodySize,
+		StreamContentTypes: defaultStreamContentTypes,
 	}
 }
 
@@ -46,6 +62,18 @@ func NewLogConfig(c *LogConfig) *LogConfig {
 		c.ExcludeOpt = &ExcludeOption{}
 	}
 
+	if c.Redactor == nil {
+		c.Redactor = log.DefaultRedactor()
+	}
+
+	if c.MaxBodySize <= 0 {
+		c.MaxBodySize = defaultMaxBodySize
+	}
+
+	if c.StreamContentTypes == nil {
+		c.StreamContentTypes = defaultStreamContentTypes
+	}
+
 	return c
 }
 
@@ -80,3 +108,11 @@ func (c *LogConfig) LogResponseBody() bool {
 
 	return c.ExcludeOpt.ResponseBody == IncludeLog
 }
+
+func (c *LogConfig) maxBodySize() int {
+	if c.MaxBodySize <= 0 {
+		return defaultMaxBodySize
+	}
+
+	return c.MaxBodySize
+}
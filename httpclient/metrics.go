@@ -0,0 +1,173 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	FieldDNSMs     = "dns_ms"
+	FieldConnectMs = "connect_ms"
+	FieldTLSMs     = "tls_ms"
+	FieldTTFBMs    = "ttfb_ms"
+	FieldErrorType = "error_type"
+)
+
+const (
+	ErrorTypeTimeout = "timeout"
+	ErrorTypeDNS     = "dns"
+	// ErrorTypeConnectionRefused is a refused or reset connection
+	ErrorTypeConnectionRefused = "connection_refused"
+	ErrorTypeTLS               = "tls"
+	ErrorTypeCanceled          = "canceled"
+	ErrorTypeOther             = "other"
+)
+
+type routeContextKey struct{}
+
+// WithRoute sets the route template of requests sent with ctx, e.g. /users/{id}, used as metrics label.
+// The JSON helpers set it from the url when WithPathParam is given.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, route)
+}
+
+// RouteFromContext returns the route template set by WithRoute, empty when not set
+func RouteFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeContextKey{}).(string)
+	return route
+}
+
+// Timing breaks down a call, phases not done on a reused connection are zero
+type Timing struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// TimeToFirstByte is from sending the request to the first response byte
+	TimeToFirstByte time.Duration
+	ConnReused      bool
+}
+
+// CallMetrics is one call sent by the doer, StatusCode is 0 and ErrorType is set when it failed
+type CallMetrics struct {
+	Host       string
+	Route      string
+	Method     string
+	StatusCode int
+	ErrorType  string
+	Duration   time.Duration
+	Timing     Timing
+}
+
+// Metrics records the calls of the doer, e.g. prommetrics.New of the httpclient/prommetrics module
+type Metrics interface {
+	// CallStarted is called before every attempt is sent
+	CallStarted(host, route, method string)
+	// CallFinished is called when the response header is received or the attempt failed
+	CallFinished(call CallMetrics)
+}
+
+// timingTrace collects the httptrace events of an attempt
+type timingTrace struct {
+	mu                       sync.Mutex
+	dnsStart, dnsDone        time.Time
+	connectStart, connectEnd time.Time
+	tlsStart, tlsDone        time.Time
+	firstByte                time.Time
+	reused                   bool
+}
+
+// withTiming returns copy of request tracing its DNS, connect, TLS and first byte times
+func withTiming(request *http.Request) (*http.Request, *timingTrace) {
+	t := &timingTrace{}
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { t.set(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.set(&t.connectEnd) },
+		TLSHandshakeStart:    func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+		},
+	}
+	return request.WithContext(httptrace.WithClientTrace(request.Context(), trace)), t
+}
+
+func (t *timingTrace) set(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*at = time.Now()
+}
+
+func (t *timingTrace) timing(start time.Time) Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return Timing{
+		DNS:             between(t.dnsStart, t.dnsDone),
+		Connect:         between(t.connectStart, t.connectEnd),
+		TLS:             between(t.tlsStart, t.tlsDone),
+		TimeToFirstByte: between(start, t.firstByte),
+		ConnReused:      t.reused,
+	}
+}
+
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// dataMap returns the breakdown as egress log fields in milliseconds, phases not done are left out
+func (t Timing) dataMap() map[string]interface{} {
+	data := make(map[string]interface{})
+	for field, duration := range map[string]time.Duration{
+		FieldDNSMs:     t.DNS,
+		FieldConnectMs: t.Connect,
+		FieldTLSMs:     t.TLS,
+		FieldTTFBMs:    t.TimeToFirstByte,
+	} {
+		if duration > 0 {
+			data[field] = float64(duration.Microseconds()) / 1000
+		}
+	}
+	return data
+}
+
+// errorType classifies err of a failed call for metrics and logs
+func errorType(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &dnsErr):
+		return ErrorTypeDNS
+	case errors.Is(err, context.Canceled):
+		return ErrorTypeCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTypeTimeout
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET):
+		return ErrorTypeConnectionRefused
+	case errors.As(err, &certErr), errors.As(err, &hostErr), errors.As(err, &recordErr):
+		return ErrorTypeTLS
+	default:
+		return ErrorTypeOther
+	}
+}
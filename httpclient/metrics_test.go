@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetrics struct {
+	mu       sync.Mutex
+	inFlight int
+	calls    []CallMetrics
+}
+
+func (m *fakeMetrics) CallStarted(host, route, method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight++
+}
+
+func (m *fakeMetrics) CallFinished(call CallMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight--
+	m.calls = append(m.calls, call)
+}
+
+func TestHttpDoer_RecordsMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	metrics := &fakeMetrics{}
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: &http.Client{}, OptLogger: logger, OptMetrics: metrics})
+
+	_, err := GetJSON[map[string]string](context.Background(), doer, server.URL+"/users/{id}", WithPathParam("id", "42"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 0, metrics.inFlight)
+	assert.Len(t, metrics.calls, 1)
+	call := metrics.calls[0]
+	assert.Equal(t, server.Listener.Addr().String(), call.Host)
+	assert.Equal(t, "/users/{id}", call.Route)
+	assert.Equal(t, http.MethodGet, call.Method)
+	assert.Equal(t, http.StatusOK, call.StatusCode)
+	assert.Empty(t, call.ErrorType)
+	assert.False(t, call.Timing.ConnReused)
+	assert.True(t, call.Timing.Connect > 0)
+	assert.True(t, call.Timing.TimeToFirstByte > 0)
+
+	entry, _ := logger.LastEntry()
+	assert.Contains(t, entry.Fields, FieldConnectMs)
+	assert.Contains(t, entry.Fields, FieldTTFBMs)
+	assert.NotContains(t, entry.Fields, FieldTLSMs)
+}
+
+func TestHttpDoer_RecordsErrorType(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	metrics := &fakeMetrics{}
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: &http.Client{}, OptLogger: logger, OptMetrics: metrics})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := doer.Do(req)
+	assert.NotNil(t, err)
+
+	assert.Len(t, metrics.calls, 1)
+	assert.Equal(t, ErrorTypeConnectionRefused, metrics.calls[0].ErrorType)
+	assert.Equal(t, "", metrics.calls[0].Route)
+	logger.AssertLogged(t, logrus.ErrorLevel, "", map[string]interface{}{FieldErrorType: ErrorTypeConnectionRefused})
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "", errorType(nil))
+	assert.Equal(t, ErrorTypeTimeout, errorType(context.DeadlineExceeded))
+	assert.Equal(t, ErrorTypeCanceled, errorType(context.Canceled))
+	assert.Equal(t, ErrorTypeOther, errorType(assert.AnError))
+}
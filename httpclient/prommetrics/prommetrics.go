@@ -0,0 +1,138 @@
+// Package prommetrics records the calls of httpclient doers as Prometheus metrics
+package prommetrics
+
+import (
+	"strconv"
+
+	"github.com/muhammad-fakhri/go-libs/httpclient"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	labelHost        = "host"
+	labelRoute       = "route"
+	labelMethod      = "method"
+	labelStatusClass = "status_class"
+	labelErrorType   = "error_type"
+	labelPhase       = "phase"
+
+	// unknownRoute labels calls without route template, see httpclient.WithRoute
+	unknownRoute     = "unknown"
+	statusClassError = "error"
+	phaseDNS         = "dns"
+	phaseConnect     = "connect"
+	phaseTLS         = "tls"
+	phaseFirstByte   = "ttfb"
+	defaultSubsystem = "http_client"
+)
+
+type Config struct {
+	Namespace  string                // prefix of the metric names, default value: none
+	Subsystem  string                // default value: http_client
+	Registerer prometheus.Registerer // default value: prometheus.DefaultRegisterer
+	Buckets    []float64             // latency histogram buckets in seconds, default value: prometheus.DefBuckets
+}
+
+type metrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	phases   *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// New registers the metrics and returns them for httpclient.ClientParam.OptMetrics:
+//   - requests_total by host, route, method and status class (2xx, 4xx, error, ...)
+//   - errors_total by host, route and error type, e.g. timeout, dns, connection_refused
+//   - request_duration_seconds histogram by host, route and method
+//   - phase_duration_seconds histogram by host and phase (dns, connect, tls, ttfb)
+//   - in_flight_requests by host and route
+func New(config Config) (httpclient.Metrics, error) {
+	if config.Subsystem == "" {
+		config.Subsystem = defaultSubsystem
+	}
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+	if config.Buckets == nil {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	m := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "requests_total",
+			Help:      "Requests sent by host, route, method and status class.",
+		}, []string{labelHost, labelRoute, labelMethod, labelStatusClass}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "errors_total",
+			Help:      "Requests failed without response by host, route and error type.",
+		}, []string{labelHost, labelRoute, labelErrorType}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Time until the response header by host, route and method.",
+			Buckets:   config.Buckets,
+		}, []string{labelHost, labelRoute, labelMethod}),
+		phases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "phase_duration_seconds",
+			Help:      "Time of the DNS, connect, TLS and time to first byte phases by host.",
+			Buckets:   config.Buckets,
+		}, []string{labelHost, labelPhase}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "in_flight_requests",
+			Help:      "Requests waiting for the response header by host and route.",
+		}, []string{labelHost, labelRoute}),
+	}
+
+	for _, collector := range []prometheus.Collector{m.requests, m.errors, m.duration, m.phases, m.inFlight} {
+		if err := config.Registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *metrics) CallStarted(host, route, method string) {
+	m.inFlight.WithLabelValues(host, routeLabel(route)).Inc()
+}
+
+func (m *metrics) CallFinished(call httpclient.CallMetrics) {
+	route := routeLabel(call.Route)
+	m.inFlight.WithLabelValues(call.Host, route).Dec()
+
+	statusClass := statusClassError
+	if call.ErrorType != "" {
+		m.errors.WithLabelValues(call.Host, route, call.ErrorType).Inc()
+	} else {
+		statusClass = strconv.Itoa(call.StatusCode/100) + "xx"
+	}
+	m.requests.WithLabelValues(call.Host, route, call.Method, statusClass).Inc()
+	m.duration.WithLabelValues(call.Host, route, call.Method).Observe(call.Duration.Seconds())
+
+	for phase, duration := range map[string]float64{
+		phaseDNS:       call.Timing.DNS.Seconds(),
+		phaseConnect:   call.Timing.Connect.Seconds(),
+		phaseTLS:       call.Timing.TLS.Seconds(),
+		phaseFirstByte: call.Timing.TimeToFirstByte.Seconds(),
+	} {
+		if duration > 0 {
+			m.phases.WithLabelValues(call.Host, phase).Observe(duration)
+		}
+	}
+}
+
+func routeLabel(route string) string {
+	if route == "" {
+		return unknownRoute
+	}
+	return route
+}
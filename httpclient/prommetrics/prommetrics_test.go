@@ -0,0 +1,55 @@
+package prommetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/httpclient"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	recorder, err := New(Config{Namespace: "test", Registerer: registry})
+	assert.Nil(t, err)
+
+	doer := httpclient.NewHttpDoWithParam(&httpclient.ClientParam{OptClient: &http.Client{}, OptLogger: logtest.New(), OptMetrics: recorder})
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req = req.WithContext(httpclient.WithRoute(req.Context(), "/health"))
+		_, err := doer.Do(req)
+		assert.Nil(t, err)
+	}
+
+	m := recorder.(*metrics)
+	host := server.Listener.Addr().String()
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.requests.WithLabelValues(host, "/health", http.MethodGet, "5xx")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.inFlight.WithLabelValues(host, "/health")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.duration))
+	// the connection is reused by the second request, so its only phase is the first byte
+	assert.Equal(t, 2, testutil.CollectAndCount(m.phases))
+
+	_, err = New(Config{Namespace: "test", Registerer: registry})
+	assert.NotNil(t, err)
+}
+
+func TestMetrics_Errors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder, _ := New(Config{Registerer: registry})
+
+	recorder.CallStarted("uuid", "", http.MethodPost)
+	recorder.CallFinished(httpclient.CallMetrics{Host: "uuid", Method: http.MethodPost, ErrorType: httpclient.ErrorTypeTimeout})
+
+	m := recorder.(*metrics)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.errors.WithLabelValues("uuid", unknownRoute, httpclient.ErrorTypeTimeout)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.requests.WithLabelValues("uuid", unknownRoute, http.MethodPost, statusClassError)))
+}
@@ -0,0 +1,32 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+)
+
+// contextHeaders maps log context data to the headers read by httpmiddleware of the upstream service
+var contextHeaders = []struct {
+	key    string
+	header string
+}{
+	{key: log.ContextIdKey, header: "X-Request-Id"},
+	{key: log.ContextCountryKey, header: "X-Country"},
+	{key: log.ContextUserIdKey, header: "X-User-Id"},
+}
+
+// injectContextHeaders sets headers of the log context data in ctx, headers set by the caller are kept
+func injectContextHeaders(ctx context.Context, header http.Header) {
+	data, ok := ctx.Value(log.ContextDataMapKey).(map[string]string)
+	if !ok {
+		return
+	}
+
+	for _, h := range contextHeaders {
+		if value := data[h.key]; value != "" && header.Get(h.header) == "" {
+			header.Set(h.header, value)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpDoer_PropagatesLogContext(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logger})
+
+	ctx := log.WithContextData(context.Background(), map[string]string{
+		log.ContextIdKey:      "req-1",
+		log.ContextCountryKey: "ID",
+		log.ContextUserIdKey:  "42",
+	})
+	ctx = log.WithFields(ctx, log.Str("order_id", "o-1"))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	req.Header.Set("X-Country", "SG")
+
+	_, err := doer.Do(req)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "req-1", received.Get("X-Request-Id"))
+	assert.Equal(t, "42", received.Get("X-User-Id"))
+	// headers set by the caller are kept
+	assert.Equal(t, "SG", received.Get("X-Country"))
+	// the caller request is left untouched
+	assert.Empty(t, req.Header.Get("X-Request-Id"))
+
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.ContextIdKey:      "req-1",
+		log.ContextCountryKey: "ID",
+		"order_id":            "o-1",
+	})
+}
+
+func TestHttpDoer_BuildsMissingLogContext(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	logger := logtest.New()
+	doer := NewHttpDoWithParam(&ClientParam{OptClient: server.Client(), OptLogger: logger})
+
+	ctx := log.WithContextData(context.Background(), map[string]string{log.ContextUserIdKey: "42"})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	req.Header.Set("X-Tenant", "TH")
+
+	_, err := doer.Do(req)
+	assert.Nil(t, err)
+
+	entry, _ := logger.LastEntry()
+	assert.Equal(t, "TH", entry.ContextData[log.ContextCountryKey])
+	assert.Equal(t, "42", entry.ContextData[log.ContextUserIdKey])
+	assert.Equal(t, 36, len(entry.ContextData[log.ContextIdKey]))
+	assert.Empty(t, received.Get("X-Request-Id"))
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver returns the instances of a service as base URLs, e.g. http://10.0.0.1:8080
+type Resolver interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+type staticResolver []string
+
+// NewStaticResolver returns resolver of a fixed list of instances
+func NewStaticResolver(instances ...string) Resolver {
+	return staticResolver(instances)
+}
+
+func (r staticResolver) Resolve(ctx context.Context) ([]string, error) {
+	return r, nil
+}
+
+type srvResolver struct {
+	name   string
+	scheme string
+	lookup func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// NewSRVResolver returns resolver of the DNS SRV records of name, e.g. _http._tcp.uuid.svc.cluster.local,
+// instances are target:port with scheme, default value of scheme: http
+func NewSRVResolver(name string, scheme string) Resolver {
+	if scheme == "" {
+		scheme = "http"
+	}
+	return &srvResolver{name: name, scheme: scheme, lookup: net.DefaultResolver.LookupSRV}
+}
+
+func (r *srvResolver) Resolve(ctx context.Context) ([]string, error) {
+	_, records, err := r.lookup(ctx, "", "", r.name)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		instances = append(instances, fmt.Sprintf("%s://%s", r.scheme, net.JoinHostPort(host, strconv.Itoa(int(record.Port)))))
+	}
+	return instances, nil
+}
+
+type fileResolver struct {
+	path string
+
+	mu        sync.Mutex
+	modTime   time.Time
+	size      int64
+	instances []string
+}
+
+// NewFileResolver returns resolver of a file listing one instance per line, blank lines and lines starting with # are skipped.
+// The file is read again when it changes, it is checked on every refresh of the load balancer.
+func NewFileResolver(path string) Resolver {
+	return &fileResolver{path: path}
+}
+
+func (r *fileResolver) Resolve(ctx context.Context) ([]string, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.instances != nil && info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+		return r.instances, nil
+	}
+
+	content, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		instances = append(instances, line)
+	}
+
+	r.modTime, r.size, r.instances = info.ModTime(), info.Size(), instances
+	return instances, nil
+}
@@ -0,0 +1,168 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	FieldAttempt = "attempt"
+
+	headerIdempotencyKey = "Idempotency-Key"
+	headerRetryAfter     = "Retry-After"
+
+	defaultRetryBaseDelay     = 100 * time.Millisecond
+	defaultRetryMaxDelay      = 5 * time.Second
+	defaultRetryMaxRetryAfter = 30 * time.Second
+)
+
+var defaultRetryStatusCodes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// RetryPolicy retries failed calls with exponential backoff and full jitter.
+// GET and HEAD are retried, other methods only with RetryNonIdempotent or an Idempotency-Key header.
+type RetryPolicy struct {
+	MaxAttempts              int           // attempts including the first one, default value: 1 (no retry)
+	BaseDelay                time.Duration // backoff of the first retry before jitter, doubled on every retry, default value: 100ms
+	MaxDelay                 time.Duration // backoff cap before jitter, default value: 5s
+	RetryStatusCodes         []int         // default value: 429, 502, 503, 504
+	DisableNetworkErrorRetry bool          // true: return network errors without retry, default value: false
+	RetryNonIdempotent       bool          // true: retry every method, default value: false
+	MaxRetryAfter            time.Duration // longest Retry-After honoured, a longer one stops retrying, default value: 30s
+}
+
+func newRetryPolicy(p *RetryPolicy) *RetryPolicy {
+	if p == nil {
+		return nil
+	}
+
+	policy := *p
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultRetryBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultRetryMaxDelay
+	}
+	if policy.RetryStatusCodes == nil {
+		policy.RetryStatusCodes = defaultRetryStatusCodes
+	}
+	if policy.MaxRetryAfter <= 0 {
+		policy.MaxRetryAfter = defaultRetryMaxRetryAfter
+	}
+	return &policy
+}
+
+// canRetry reports whether request may be sent again, its body must be replayable through GetBody
+func (p *RetryPolicy) canRetry(request *http.Request) bool {
+	if p == nil || p.MaxAttempts <= 1 {
+		return false
+	}
+	return replayable(request, p.RetryNonIdempotent)
+}
+
+// replayable reports whether request is safe to send again, GET and HEAD always are,
+// other methods with nonIdempotent or an Idempotency-Key header
+func replayable(request *http.Request, nonIdempotent bool) bool {
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return false
+	}
+
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead:
+		return true
+	}
+	return nonIdempotent || request.Header.Get(headerIdempotencyKey) != ""
+}
+
+// backoff returns delay before the next attempt, false when attempt should not be retried
+func (p *RetryPolicy) backoff(attempt int, response *http.Response, httpErr error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	if httpErr != nil {
+		if p.DisableNetworkErrorRetry || !isNetworkError(httpErr) {
+			return 0, false
+		}
+		return p.jitter(attempt), true
+	}
+
+	if !p.retryStatus(response.StatusCode) {
+		return 0, false
+	}
+	if delay, ok := retryAfter(response.Header.Get(headerRetryAfter)); ok {
+		return delay, delay <= p.MaxRetryAfter
+	}
+	return p.jitter(attempt), true
+}
+
+func (p *RetryPolicy) retryStatus(status int) bool {
+	for _, code := range p.RetryStatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// jitter returns a random delay up to the exponential backoff of attempt
+func (p *RetryPolicy) jitter(attempt int) time.Duration {
+	backoff := p.BaseDelay
+	for i := 1; i < attempt && backoff < p.MaxDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// isNetworkError reports transport errors, cancellation of the request context is checked by the caller
+func isNetworkError(err error) bool {
+	// every error of http.Client is an url.Error, which is a net.Error itself
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryAfter parses Retry-After given in seconds or as HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleep waits for delay, returns early with the error of ctx
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
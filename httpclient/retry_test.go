@@ -0,0 +1,173 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyServer answers failures with status until the given attempt succeeds, it records the request bodies
+func flakyServer(status int, successAttempt int32, header http.Header) (*httptest.Server, *int32, *[]string) {
+	var attempts int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if atomic.AddInt32(&attempts, 1) < successAttempt {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	return server, &attempts, &bodies
+}
+
+func newRetryDoer(client *http.Client, retry *RetryPolicy) (HttpDoer, *logtest.Logger) {
+	logger := logtest.New()
+	return NewHttpDoWithParam(&ClientParam{OptClient: client, OptLogger: logger, OptRetry: retry}), logger
+}
+
+func TestRetry_StatusCode(t *testing.T) {
+	server, attempts, _ := flakyServer(http.StatusServiceUnavailable, 3, nil)
+	defer server.Close()
+	doer, logger := newRetryDoer(server.Client(), &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	body, status, err := doer.DoV2(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"ok":true}`, string(body))
+	assert.Equal(t, int32(3), *attempts)
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{FieldAttempt: 1, "status": http.StatusServiceUnavailable})
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{FieldAttempt: 3, "status": http.StatusOK})
+}
+
+func TestRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	server, attempts, _ := flakyServer(http.StatusBadGateway, 10, nil)
+	defer server.Close()
+	doer, logger := newRetryDoer(server.Client(), &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, status, err := doer.DoV2(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Equal(t, int32(2), *attempts)
+	assert.Equal(t, 2, len(logger.Entries()))
+}
+
+func TestRetry_NonIdempotent(t *testing.T) {
+	server, attempts, bodies := flakyServer(http.StatusServiceUnavailable, 2, nil)
+	defer server.Close()
+	doer, _ := newRetryDoer(server.Client(), &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"amount":10}`))
+	_, status, _ := doer.DoV2(req)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, int32(1), *attempts)
+
+	atomic.StoreInt32(attempts, 0)
+	*bodies = nil
+	req, _ = http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"amount":10}`))
+	req.Header.Set("Idempotency-Key", "order-1")
+	_, status, _ = doer.DoV2(req)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{`{"amount":10}`, `{"amount":10}`}, *bodies)
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	server, attempts, _ := flakyServer(http.StatusTooManyRequests, 2, http.Header{"Retry-After": []string{"0"}})
+	defer server.Close()
+	doer, _ := newRetryDoer(server.Client(), &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, status, err := doer.DoV2(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int32(2), *attempts)
+
+	server, attempts, _ = flakyServer(http.StatusTooManyRequests, 2, http.Header{"Retry-After": []string{"120"}})
+	defer server.Close()
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	_, status, _ = doer.DoV2(req)
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	assert.Equal(t, int32(1), *attempts)
+}
+
+func TestRetry_NetworkError(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	// without keep alive the transport can't retry on a fresh connection by itself
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	doer, logger := newRetryDoer(client, &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	body, err := doer.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", string(body))
+	logger.AssertLogged(t, logrus.ErrorLevel, "EOF", map[string]interface{}{FieldAttempt: 1})
+
+	doer, _ = newRetryDoer(client, &RetryPolicy{MaxAttempts: 2, DisableNetworkErrorRetry: true})
+	atomic.StoreInt32(&attempts, 0)
+	_, err = doer.Do(req)
+	assert.NotNil(t, err)
+}
+
+func TestRetry_ContextCanceledDuringBackoff(t *testing.T) {
+	server, attempts, _ := flakyServer(http.StatusServiceUnavailable, 10, nil)
+	defer server.Close()
+	doer, _ := newRetryDoer(server.Client(), &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	_, err := doer.Do(req)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := newRetryPolicy(&RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+	for attempt := 1; attempt < 10; attempt++ {
+		delay := policy.jitter(attempt)
+		assert.True(t, delay >= 0 && delay <= time.Second)
+		if attempt == 1 {
+			assert.True(t, delay <= 100*time.Millisecond)
+		}
+	}
+
+	delay, ok := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.True(t, delay > 58*time.Second && delay <= time.Minute)
+	_, ok = retryAfter("soon")
+	assert.False(t, ok)
+
+	assert.Nil(t, newRetryPolicy(nil))
+	assert.False(t, policy.canRetry(&http.Request{Method: http.MethodPost, Header: http.Header{}}))
+	assert.True(t, policy.canRetry(&http.Request{Method: http.MethodDelete, Header: http.Header{"Idempotency-Key": []string{"k"}}}))
+	assert.False(t, policy.canRetry(&http.Request{Method: http.MethodGet, Body: ioutil.NopCloser(strings.NewReader("x"))}))
+}
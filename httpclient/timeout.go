@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const defaultDeadlineMargin = 50 * time.Millisecond
+
+// TimeoutPolicy times out every attempt on its own instead of http.Client.Timeout covering the whole call.
+// The timeout of an attempt is Timeout, shortened to the deadline of the request context minus DeadlineMargin,
+// so the caller still has time to answer its own caller.
+type TimeoutPolicy struct {
+	Timeout        time.Duration // default value: ClientParam timeout, 5s
+	DeadlineMargin time.Duration // default value: 50ms
+}
+
+func newTimeoutPolicy(p *TimeoutPolicy, timeout time.Duration) *TimeoutPolicy {
+	if p == nil {
+		return nil
+	}
+
+	policy := *p
+	if policy.Timeout <= 0 {
+		policy.Timeout = timeout
+	}
+	if policy.DeadlineMargin <= 0 {
+		policy.DeadlineMargin = defaultDeadlineMargin
+	}
+	return &policy
+}
+
+// withTimeout returns copy of request with the attempt timeout, cancel must be called once the response body is closed
+func (p *TimeoutPolicy) withTimeout(request *http.Request) (*http.Request, context.CancelFunc) {
+	if p == nil {
+		return request, func() {}
+	}
+
+	timeout := p.Timeout
+	if deadline, ok := request.Context().Deadline(); ok {
+		if remaining := time.Until(deadline) - p.DeadlineMargin; remaining < timeout {
+			timeout = remaining
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), timeout)
+	return request.WithContext(ctx), cancel
+}
+
+// cancelOnClose cancels the attempt context when the response body is closed, right away when there is no body
+func cancelOnClose(response *http.Response, cancel context.CancelFunc) {
+	if response == nil || response.Body == nil {
+		cancel()
+		return
+	}
+	response.Body = &releaseBody{ReadCloser: response.Body, release: cancel}
+}
@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpDoer_TimeoutPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	doer := NewHttpDoWithParam(&ClientParam{
+		OptLogger:        logtest.New(),
+		OptTimeoutPolicy: &TimeoutPolicy{Timeout: time.Second, DeadlineMargin: 100 * time.Millisecond},
+	})
+
+	// the response body is still readable after the call returned
+	body, err := doer.Do(mustRequest(t, context.Background(), server.URL+"/fast"))
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", string(body))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err = doer.Do(mustRequest(t, ctx, server.URL+"/slow"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	// the attempt gave up at the deadline minus the margin
+	assert.True(t, time.Since(started) < 100*time.Millisecond)
+	assert.Nil(t, ctx.Err())
+}
+
+func TestClientParam_TimeoutPolicyClient(t *testing.T) {
+	param := &ClientParam{OptTimeout: time.Second, OptTimeoutPolicy: &TimeoutPolicy{}}
+	assert.Equal(t, time.Duration(0), param.GetClient().Timeout)
+
+	policy := newTimeoutPolicy(param.OptTimeoutPolicy, param.GetTimeout())
+	assert.Equal(t, time.Second, policy.Timeout)
+	assert.Equal(t, defaultDeadlineMargin, policy.DeadlineMargin)
+}
+
+func mustRequest(t *testing.T, ctx context.Context, url string) *http.Request {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	assert.Nil(t, err)
+	return req
+}
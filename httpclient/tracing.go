@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/muhammad-fakhri/go-libs/httpclient"
+
+// propagator writes W3C traceparent, tracestate and baggage headers
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// startSpan starts a client span as child of the span in request context.
+// The returned copy of request carries the span in its context, traceparent and log context data in its header.
+func startSpan(request *http.Request) (*http.Request, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(request.Context(), "HTTP "+request.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(request)...),
+	)
+
+	outgoing := request.Clone(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(outgoing.Header))
+	injectContextHeaders(ctx, outgoing.Header)
+	return outgoing, span
+}
+
+func endSpan(span trace.Span, response *http.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if response != nil {
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(response.StatusCode)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(response.StatusCode, trace.SpanKindClient))
+	}
+	span.End()
+}
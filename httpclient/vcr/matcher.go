@@ -0,0 +1,52 @@
+package vcr
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+)
+
+// Matcher reports whether request, as it would be saved, matches the recorded request
+type Matcher func(request, recorded *Request) bool
+
+func MatchMethod(request, recorded *Request) bool {
+	return request.Method == recorded.Method
+}
+
+// MatchURL compares scheme, host, path and query, query params in any order
+func MatchURL(request, recorded *Request) bool {
+	a, errA := url.Parse(request.URL)
+	b, errB := url.Parse(recorded.URL)
+	if errA != nil || errB != nil {
+		return request.URL == recorded.URL
+	}
+	return a.Scheme == b.Scheme && a.Host == b.Host && a.Path == b.Path && reflect.DeepEqual(a.Query(), b.Query())
+}
+
+// MatchPath compares only the path, e.g. when the host differs between environments
+func MatchPath(request, recorded *Request) bool {
+	a, errA := url.Parse(request.URL)
+	b, errB := url.Parse(recorded.URL)
+	return errA == nil && errB == nil && a.Path == b.Path
+}
+
+// MatchBody compares JSON bodies by value, other bodies byte by byte
+func MatchBody(request, recorded *Request) bool {
+	var a, b interface{}
+	if json.Unmarshal([]byte(request.Body), &a) == nil && json.Unmarshal([]byte(recorded.Body), &b) == nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return request.Body == recorded.Body
+}
+
+// MatchHeader returns matcher comparing the values of the given headers
+func MatchHeader(names ...string) Matcher {
+	return func(request, recorded *Request) bool {
+		for _, name := range names {
+			if !reflect.DeepEqual(request.Header.Values(name), recorded.Header.Values(name)) {
+				return false
+			}
+		}
+		return true
+	}
+}
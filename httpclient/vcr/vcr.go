@@ -0,0 +1,285 @@
+// Package vcr records real HTTP interactions to a cassette file and replays them in tests,
+// e.g. as transport of httpclient.ClientParam.OptClient:
+//
+//	recorder := vcr.New(t, vcr.Config{Path: "testdata/partner.yaml", Mode: vcr.ModeFromEnv("VCR_MODE")})
+//	doer := httpclient.NewHttpDoWithParam(&httpclient.ClientParam{OptClient: recorder.Client()})
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnmatched is returned, wrapped with the request, in replay mode when no recorded interaction matches
+var ErrUnmatched = errors.New("vcr: no recorded interaction")
+
+type Mode int
+
+const (
+	// ModeReplay serves recorded interactions and never calls the network
+	ModeReplay = Mode(iota)
+	// ModeRecord calls the network and saves every interaction to the cassette, replacing it
+	ModeRecord
+)
+
+// ModeFromEnv returns ModeRecord when the environment variable key is "record", ModeReplay otherwise
+func ModeFromEnv(key string) Mode {
+	if strings.EqualFold(os.Getenv(key), "record") {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+type Config struct {
+	Path      string            // cassette file, YAML when it ends with .yaml or .yml, JSON otherwise, required
+	Mode      Mode              // default value: ModeReplay
+	Transport http.RoundTripper // calls the network in record mode, default value: http.DefaultTransport
+	// Redactor masks headers, query parameters and bodies before they are saved, requests are matched after masking too,
+	// default value: credential headers and query parameters, bodies are saved as they are
+	Redactor *log.Redactor
+	Matchers []Matcher // all must match, default value: MatchMethod, MatchURL, MatchBody
+}
+
+// defaultRedactor masks credentials only, so recorded bodies still match and replay like the real ones
+func defaultRedactor() *log.Redactor {
+	return log.NewRedactor(
+		log.RedactHeaders(log.MaskFull, "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "*token*", "*secret*"),
+		log.RedactQueryParams(log.MaskFull, "*token*", "*secret*", "api_key", "apikey", "password", "sig", "signature"),
+	)
+}
+
+// Cassette is the file content, one interaction per call in calling order
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method" yaml:"method"`
+	URL    string      `json:"url" yaml:"url"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code" yaml:"status_code"`
+	Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording or replaying the interactions of a cassette
+type Recorder struct {
+	t      testing.TB
+	config Config
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     map[*Interaction]bool
+}
+
+// New returns recorder of config.Path. In replay mode t fails when the cassette can't be loaded or a request is unmatched,
+// in record mode the cassette is saved when t is cleaned up.
+func New(t testing.TB, config Config) *Recorder {
+	t.Helper()
+
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	if config.Redactor == nil {
+		config.Redactor = defaultRedactor()
+	}
+	if config.Matchers == nil {
+		config.Matchers = []Matcher{MatchMethod, MatchURL, MatchBody}
+	}
+
+	r := &Recorder{t: t, config: config, cassette: &Cassette{}, used: make(map[*Interaction]bool)}
+	if config.Mode == ModeRecord {
+		t.Cleanup(func() {
+			if err := r.Save(); err != nil {
+				t.Errorf("vcr: save cassette %s: %v", config.Path, err)
+			}
+		})
+		return r
+	}
+
+	cassette, err := Load(config.Path)
+	if err != nil {
+		t.Fatalf("vcr: load cassette %s, record it with ModeRecord: %v", config.Path, err)
+	}
+	r.cassette = cassette
+	return r
+}
+
+// Client returns http.Client with the recorder as transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	recorded, err := r.request(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.config.Mode == ModeRecord {
+		return r.record(request, recorded)
+	}
+	return r.replay(request, recorded)
+}
+
+func (r *Recorder) record(request *http.Request, recorded Request) (*http.Response, error) {
+	response, err := r.config.Transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Header:     r.config.Redactor.RedactHeader(response.Header),
+			Body:       r.config.Redactor.RedactBody(string(body)),
+		},
+	})
+	return response, nil
+}
+
+func (r *Recorder) replay(request *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, interaction := range r.cassette.Interactions {
+		if r.used[interaction] || !r.matches(&recorded, &interaction.Request) {
+			continue
+		}
+		r.used[interaction] = true
+
+		body := []byte(interaction.Response.Body)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       request,
+		}, nil
+	}
+
+	err := fmt.Errorf("%w for %s %s in %s", ErrUnmatched, recorded.Method, recorded.URL, r.config.Path)
+	r.t.Errorf("%v, body: %s", err, recorded.Body)
+	return nil, err
+}
+
+func (r *Recorder) matches(request, recorded *Request) bool {
+	for _, match := range r.config.Matchers {
+		if !match(request, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// request returns request as saved in the cassette, the request body is read and restored
+func (r *Recorder) request(request *http.Request) (Request, error) {
+	var body []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		var err error
+		if body, err = ioutil.ReadAll(request.Body); err != nil {
+			return Request{}, err
+		}
+		request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return Request{
+		Method: request.Method,
+		URL:    r.config.Redactor.RedactURL(request.URL.String()),
+		Header: r.config.Redactor.RedactHeader(request.Header.Clone()),
+		Body:   r.config.Redactor.RedactBody(string(body)),
+	}, nil
+}
+
+// Unused returns the recorded interactions not replayed yet, e.g. to assert every expected call was made
+func (r *Recorder) Unused() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []*Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if !r.used[interaction] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// Save writes the recorded interactions to the cassette file, creating its directory
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := marshal(r.config.Path, r.cassette)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.config.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.config.Path, content, 0644)
+}
+
+// Load reads the cassette at path
+func Load(path string) (*Cassette, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{}
+	if isYAML(path) {
+		err = yaml.Unmarshal(content, cassette)
+	} else {
+		err = json.Unmarshal(content, cassette)
+	}
+	return cassette, err
+}
+
+func marshal(path string, cassette *Cassette) ([]byte, error) {
+	if isYAML(path) {
+		return yaml.Marshal(cassette)
+	}
+	return json.MarshalIndent(cassette, "", "  ")
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
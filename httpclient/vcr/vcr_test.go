@@ -0,0 +1,145 @@
+package vcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/httpclient"
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type loginRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token string `json:"token"`
+	Name  string `json:"name"`
+}
+
+// failureTB captures the failures of the recorder instead of failing the test
+type failureTB struct {
+	testing.TB
+	failures []string
+}
+
+func (t *failureTB) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func newPartnerServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=s3cr3t")
+		fmt.Fprintf(w, `{"token":"t0k3n","name":"%s"}`, r.URL.Query().Get("name"))
+	}))
+}
+
+func login(t *testing.T, client *http.Client, baseURL string) (loginResponse, error) {
+	doer := httpclient.NewHttpDoWithParam(&httpclient.ClientParam{OptClient: client, OptLogger: logtest.New()})
+	return httpclient.PostJSON[loginRequest, loginResponse](context.Background(), doer, baseURL+"/login?name=fakhri&access_token=x9z",
+		loginRequest{User: "fakhri", Password: "hunter2"}, httpclient.WithHeader("Authorization", "Bearer abc"))
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	for _, name := range []string{"partner.yaml", "partner.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassettes", name)
+			server := newPartnerServer()
+
+			t.Run("record", func(t *testing.T) {
+				recorder := New(t, Config{Path: path, Mode: ModeRecord})
+				response, err := login(t, recorder.Client(), server.URL)
+				assert.Nil(t, err)
+				assert.Equal(t, loginResponse{Token: "t0k3n", Name: "fakhri"}, response)
+			})
+			server.Close()
+
+			content, err := ioutil.ReadFile(path)
+			assert.Nil(t, err)
+			for _, secret := range []string{"Bearer abc", "s3cr3t", "x9z"} {
+				assert.NotContains(t, string(content), secret)
+			}
+			// bodies are only masked by a configured redactor
+			assert.Contains(t, string(content), "hunter2")
+
+			recorder := New(t, Config{Path: path})
+			response, err := login(t, recorder.Client(), server.URL)
+			assert.Nil(t, err)
+			assert.Equal(t, "fakhri", response.Name)
+			assert.Empty(t, recorder.Unused())
+		})
+	}
+}
+
+func TestRecord_Redactor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partner.json")
+	server := newPartnerServer()
+	defer server.Close()
+
+	recorder := New(t, Config{Path: path, Mode: ModeRecord, Redactor: log.DefaultRedactor()})
+	_, err := login(t, recorder.Client(), server.URL)
+	assert.Nil(t, err)
+	assert.Nil(t, recorder.Save())
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	for _, secret := range []string{"hunter2", "Bearer abc", "t0k3n", "s3cr3t", "x9z"} {
+		assert.NotContains(t, string(content), secret)
+	}
+
+	replay := New(t, Config{Path: path, Redactor: log.DefaultRedactor()})
+	_, err = login(t, replay.Client(), server.URL)
+	assert.Nil(t, err)
+	assert.Empty(t, replay.Unused())
+}
+
+func TestReplay_Unmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partner.json")
+	server := newPartnerServer()
+	defer server.Close()
+
+	recorder := New(t, Config{Path: path, Mode: ModeRecord})
+	_, err := login(t, recorder.Client(), server.URL)
+	assert.Nil(t, err)
+	assert.Nil(t, recorder.Save())
+
+	tb := &failureTB{TB: t}
+	replay := New(tb, Config{Path: path})
+
+	// the interaction is replayed once only
+	_, err = login(t, replay.Client(), server.URL)
+	assert.Nil(t, err)
+	_, err = login(t, replay.Client(), server.URL)
+	assert.True(t, errors.Is(err, ErrUnmatched))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/logout", nil)
+	_, err = replay.Client().Do(req)
+	assert.True(t, errors.Is(err, ErrUnmatched))
+
+	assert.Len(t, tb.failures, 2)
+	assert.True(t, strings.Contains(tb.failures[1], "GET "+server.URL+"/logout"))
+}
+
+func TestMatchers(t *testing.T) {
+	recorded := &Request{Method: http.MethodPost, URL: "http://a/x?b=2&a=1", Body: `{"a":1,"b":[1,2]}`,
+		Header: http.Header{"X-Country": {"ID"}}}
+
+	assert.True(t, MatchURL(&Request{URL: "http://a/x?a=1&b=2"}, recorded))
+	assert.False(t, MatchURL(&Request{URL: "http://b/x?a=1&b=2"}, recorded))
+	assert.True(t, MatchPath(&Request{URL: "http://b/x"}, recorded))
+	assert.True(t, MatchBody(&Request{Body: `{"b":[1,2], "a":1}`}, recorded))
+	assert.False(t, MatchBody(&Request{Body: `{"b":[2,1],"a":1}`}, recorded))
+	assert.True(t, MatchHeader("X-Country")(&Request{Header: http.Header{"X-Country": {"ID"}}}, recorded))
+	assert.False(t, MatchHeader("X-Country")(&Request{}, recorded))
+}
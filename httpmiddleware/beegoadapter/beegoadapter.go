@@ -0,0 +1,39 @@
+// Package beegoadapter applies httpmiddleware middlewares to a beego application:
+//
+//	beego.RunWithMiddleWares(":8080", beegoadapter.MiddleWare(
+//		httpmiddleware.RequestID(),
+//		httpmiddleware.ContextEnricher(logger),
+//		httpmiddleware.AccessLog(logger),
+//		httpmiddleware.Recoverer(logger),
+//	))
+//
+// The route template of beego controllers is recorded for the ingress log by RecordRoute:
+//
+//	beego.InsertFilter("*", beego.AfterExec, beegoadapter.RecordRoute, false)
+package beegoadapter
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"github.com/muhammad-fakhri/go-libs/httpmiddleware"
+)
+
+// MiddleWare returns the chain of middlewares as beego.MiddleWare, the first one is the outermost
+func MiddleWare(middlewares ...httpmiddleware.Middleware) beego.MiddleWare {
+	return beego.MiddleWare(httpmiddleware.Chain(middlewares...))
+}
+
+// Handler applies the chain of middlewares to handler of a beego controller method,
+// e.g. beego.Handler("/hello", beegoadapter.Handler(hello, middlewares...))
+func Handler(handler http.Handler, middlewares ...httpmiddleware.Middleware) http.Handler {
+	return httpmiddleware.Chain(middlewares...).Then(handler)
+}
+
+// RecordRoute is a beego filter recording the route template of the request, e.g. "/users/:id", see httpmiddleware.SetRoute
+func RecordRoute(ctx *context.Context) {
+	if pattern, ok := ctx.Input.GetData("RouterPattern").(string); ok {
+		httpmiddleware.SetRoute(ctx.Request, pattern)
+	}
+}
@@ -1,9 +1,33 @@
 package httpmiddleware
 
+import (
+	"github.com/muhammad-fakhri/go-libs/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
 type Config struct {
 	ExcludeOpt        *ExcludeOption
 	DisableIngressLog bool // true: add important info to context and disable default ingress log (usecase: custom logging implementation), default value: false
 	FieldOpt          *FieldOption
+	Redactor          *log.Redactor        // masks headers and bodies before logging, default value: log.DefaultRedactor()
+	TracerProvider    trace.TracerProvider // starts a span for every request, default value: otel.GetTracerProvider()
+	// ForceDebugHeader is the request header enabling debug logs of the request when its value is true, e.g. X-Force-Debug.
+	// Forced debug logs skip sampling, set it only when the header can't be sent by untrusted clients, default value: "" (disabled)
+	ForceDebugHeader string
+	// MaxBodySize is the bytes of the response body kept for logging, longer bodies are truncated, default value: 64 KiB.
+	// Nothing is kept when the response body is excluded. A truncated JSON body has the values of keys named like a field rule
+	// of the redactor masked, see log.Redactor.RedactBody.
+	MaxBodySize int
+	// Recoverer is the response of a recovered panic, default value: the defaults of RecovererConfig
+	Recoverer *RecovererConfig
+	// Routes overrides the config by route template, e.g. "/users/:id", or by url path when the route is not known
+	Routes map[string]*RouteConfig
+}
+
+// RouteConfig overrides Config for the requests of a route
+type RouteConfig struct {
+	DisableIngressLog bool           // true: skip the ingress log of the route, e.g. health checks, default value: false
+	ExcludeOpt        *ExcludeOption // replaces Config.ExcludeOpt, e.g. exclude the bodies of /login, default value: Config.ExcludeOpt
 }
 
 type ExcludeOption struct {
@@ -23,6 +47,7 @@ type FieldOption struct {
 func defaultConfig() *Config {
 	return &Config{
 		ExcludeOpt: &ExcludeOption{},
+		Redactor:   log.DefaultRedactor(),
 	}
 }
 
@@ -31,6 +56,10 @@ func NewConfig(c *Config) *Config {
 		c.ExcludeOpt = &ExcludeOption{}
 	}
 
+	if c.Redactor == nil {
+		c.Redactor = log.DefaultRedactor()
+	}
+
 	return c
 }
 
@@ -82,6 +111,35 @@ func (c *Config) LogFailedRequestOnly() bool {
 	return c.ExcludeOpt.SuccessRequest == ExcludeLog
 }
 
+// forRoute returns copy of the config with the overrides of route, or of path when route has none
+func (c *Config) forRoute(route, path string) *Config {
+	override, ok := c.Routes[route]
+	if !ok {
+		override, ok = c.Routes[path]
+	}
+	if !ok || override == nil {
+		return c
+	}
+
+	config := *c
+	config.DisableIngressLog = c.DisableIngressLog || override.DisableIngressLog
+	if override.ExcludeOpt != nil {
+		config.ExcludeOpt = override.ExcludeOpt
+	}
+	return &config
+}
+
+// responseBodySize returns the bytes of the response body to capture
+func (c *Config) responseBodySize() int {
+	if !c.LogResponseBody() {
+		return 0
+	}
+	if c.MaxBodySize <= 0 {
+		return defaultMaxBodySize
+	}
+	return c.MaxBodySize
+}
+
 func (c *Config) GetEventPrefix() string {
 	if c.FieldOpt == nil || len(c.FieldOpt.EventPrefix) == 0 {
 		return EventPrefix + URLSeparator
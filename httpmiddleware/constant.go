@@ -11,9 +11,14 @@ const (
 	headerNameTenant    = "x-tenant"
 	headerNameCountry   = "x-country"
 
+	defaultMaxBodySize = 64 << 10
+
 	ContextUserIdKey  = "user_id"
 	ContextEventIDKey = "event_id"
 
+	FieldResponseSize = "rsp_size"
+	FieldRoute        = "route"
+
 	EventPrefix  = "events"
 	URLSeparator = "/"
 )
@@ -24,11 +24,19 @@ func main() {
 	handlerPanic := logIngressMid.Enforce(http.HandlerFunc(HelloPanic))
 	// to override the ingress middleware's panic handler, place your custom handler first like this.
 	handlerPanic2 := logIngressMid.Enforce(RecoverWrap(http.HandlerFunc(HelloPanic)))
+	// or compose the middlewares, the panic response doesn't show the panic value
+	handlerChain := middleware.Chain(
+		middleware.RequestID(),
+		middleware.ContextEnricher(logger),
+		middleware.AccessLog(logger),
+		middleware.Recoverer(logger, &middleware.RecovererConfig{Body: []byte("service currently unavailable")}),
+	).ThenFunc(HelloPanic)
 
 	http.Handle("/hello", handler)
 	http.Handle("/hello-panic", handlerPanic)
 	http.Handle("/hello-panic-wrap", handlerPanic2)
 	http.Handle("/hello-request-id", handlerReqID)
+	http.Handle("/hello-chain", handlerChain)
 
 	logger.Info(context.TODO(), "web starting in 8181")
 	if err := http.ListenAndServe(":8181", nil); err != nil {
@@ -3,11 +3,15 @@ module github.com/muhammad-fakhri/go-libs/httpmiddleware
 go 1.13
 
 require (
+	github.com/astaxie/beego v1.12.1
 	github.com/c2fo/testify v0.0.0-20150827203832-fba96363964a
 	github.com/google/uuid v1.1.1
 	github.com/julienschmidt/httprouter v1.3.0
-	github.com/muhammad-fakhri/go-libs/log v1.0.0
+	github.com/muhammad-fakhri/go-libs/log v1.1.0
 	github.com/rs/cors v1.7.0
+	github.com/shiena/ansicolor v0.0.0-20151119151921-a422bbe96644 // indirect
 	github.com/sirupsen/logrus v1.6.0
-	golang.org/x/sys v0.0.0-20200602225109-6fdc65e7d980 // indirect
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 )
@@ -7,7 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -45,6 +45,8 @@ const (
 
 type LogRequest struct {
 	URL    string
+	Path   string
+	Route  string // route template, e.g. /users/:id, empty when the router is not known
 	Method string
 	Header http.Header
 	Body   string
@@ -65,43 +67,15 @@ func NewIngressLogMiddleware(logger log.SLogger, optionalConfig ...*Config) *Ing
 	}
 }
 
-// Enforce is to apply log ingress middleware to the 'next' handler
+// Enforce is to apply log ingress middleware to the 'next' handler,
+// it chains ContextEnricher, AccessLog and Recoverer
 func (i *IngressLog) Enforce(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		logReqMessage := buildLogRequest(r)
-
-		newRequest := i.appendContextDataAndSetValue(r, i.logger)
-		newWriter := NewResponseWriter(w)
-
-		var (
-			startTime       time.Time
-			elapsedTimeInMS int64
-		)
-
-		defer func(ctx context.Context, reqmes *LogRequest, elapsedTimeInMS *int64, requestTimestamp *time.Time, writer *LogResponseWriter) {
-			r := recover()
-			if r != nil {
-				fmt.Println("[ingress][panic] recovered from: ", r)
-				debug.PrintStack()
-
-				// default panic value
-				writer.WriteHeader(http.StatusInternalServerError)
-				writer.Write([]byte(fmt.Sprintf("panic: %v.", r)))
-			}
-
-			i.log(newRequest.Context(), reqmes, *elapsedTimeInMS, *requestTimestamp, writer)
-
-		}(newRequest.Context(), logReqMessage, &elapsedTimeInMS, &startTime, newWriter)
-
-		startTime = time.Now()
-		next.ServeHTTP(newWriter, newRequest)
-		elapsedTimeInMS = time.Since(startTime).Milliseconds()
-
-	})
+	return i.chain().Then(next)
 }
 
 func (i *IngressLog) log(ctx context.Context, request *LogRequest, timeTaken int64, requestTimestamp time.Time, rw *LogResponseWriter) {
-	if i.config.DisableIngressLog || (i.config.LogFailedRequestOnly() && rw.statusCode == http.StatusOK) {
+	config := i.config.forRoute(request.Route, request.Path)
+	if config.DisableIngressLog || (config.LogFailedRequestOnly() && rw.statusCode == http.StatusOK) {
 		// skip ingress log, rely on load balancer log or custom log instead
 		return
 	}
@@ -112,31 +86,35 @@ func (i *IngressLog) log(ctx context.Context, request *LogRequest, timeTaken int
 		RequestTimestamp: requestTimestamp,
 		Status:           rw.statusCode,
 		DurationMs:       timeTaken,
+		DataMap:          map[string]interface{}{FieldResponseSize: rw.bytesWritten},
+	}
+	if request.Route != "" {
+		data.DataMap[FieldRoute] = request.Route
 	}
 
-	if i.config.LogResponseHeader() {
+	if config.LogResponseHeader() {
 		header := rw.Header().Clone()
 		header.Del("Authorization")
 		data.ResponseHeader = header
 	}
 
-	if i.config.LogResponseBody() {
-		if i.config.LogSuccessResponseBody() {
-			data.ResponseBody = rw.bodyBuffer.String()
+	if config.LogResponseBody() {
+		if config.LogSuccessResponseBody() {
+			data.ResponseBody = rw.Body()
 		} else {
 			if rw.statusCode != http.StatusOK {
-				data.ResponseBody = rw.bodyBuffer.String()
+				data.ResponseBody = rw.Body()
 			} else {
 				data.ResponseBody = wipedMessage
 			}
 		}
 	}
 
-	if i.config.LogRequestHeader() {
+	if config.LogRequestHeader() {
 		header := request.Header.Clone()
 		header.Del("Authorization")
 
-		excludeRequestHeaderKeys := i.config.ExcludeOpt.RequestHeaderKeys
+		excludeRequestHeaderKeys := config.ExcludeOpt.RequestHeaderKeys
 		if excludeRequestHeaderKeys != nil && len(excludeRequestHeaderKeys) > 0 {
 			for _, headerKey := range excludeRequestHeaderKeys {
 				header.Del(headerKey)
@@ -146,47 +124,24 @@ func (i *IngressLog) log(ctx context.Context, request *LogRequest, timeTaken int
 		data.RequestHeader = header
 	}
 
-	if i.config.LogRequestBody() {
+	if config.LogRequestBody() {
 		data.RequestBody = request.Body
 	}
 
+	config.Redactor.RedactRequestResponse(data)
 	i.logger.LogRequestResponse(ctx, data)
 }
 
 // Enforce is to apply log ingress middleware to the 'next' handler. Like http.HandlerFunc,
 // but has a third parameter for the values of wildcards (variables), e.g: github.com/julienschmidt/httprouter
 func (i *IngressLog) EnforceWithParams(next httprouter.Handle) httprouter.Handle {
-	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		logReqMessage := buildLogRequest(r)
-
-		newRequest := i.appendContextDataAndSetValue(r, i.logger)
-		newWriter := NewResponseWriter(w)
-
-		var (
-			startTime       time.Time
-			elapsedTimeInMS int64
-		)
-
-		defer func(ctx context.Context, reqmes *LogRequest, elapsedTimeInMS *int64, requestTimestamp *time.Time, writer *LogResponseWriter) {
-			r := recover()
-			if r != nil {
-				fmt.Println("[ingress][panic] recovered from: ", r)
-				debug.PrintStack()
-
-				// default panic value
-				writer.WriteHeader(http.StatusInternalServerError)
-				writer.Write([]byte(fmt.Sprintf("panic: %v.", r)))
-			}
-
-			i.log(newRequest.Context(), reqmes, *elapsedTimeInMS, *requestTimestamp, writer)
-
-		}(newRequest.Context(), logReqMessage, &elapsedTimeInMS, &startTime, newWriter)
-
-		startTime = time.Now()
-		next(newWriter, newRequest, ps)
-		elapsedTimeInMS = time.Since(startTime).Milliseconds()
+	return i.chain().WithParams("", next)
+}
 
-	}
+// EnforceWithRoute is EnforceWithParams recording pattern as the route of the ingress log,
+// e.g. router.GET("/users/:id", ingress.EnforceWithRoute("/users/:id", handle))
+func (i *IngressLog) EnforceWithRoute(pattern string, next httprouter.Handle) httprouter.Handle {
+	return i.chain().WithParams(pattern, next)
 }
 
 func (i *IngressLog) appendContextDataAndSetValue(r *http.Request, l log.SLogger) *http.Request {
@@ -219,9 +174,23 @@ func (i *IngressLog) appendContextDataAndSetValue(r *http.Request, l log.SLogger
 	return r.WithContext(l.SetContextData(r.Context(), data))
 }
 
+// forceDebug enables debug logs for the request when its force debug header is true
+func (i *IngressLog) forceDebug(r *http.Request) *http.Request {
+	if i.config.ForceDebugHeader == "" {
+		return r
+	}
+
+	if forced, _ := strconv.ParseBool(r.Header.Get(i.config.ForceDebugHeader)); !forced {
+		return r
+	}
+
+	return r.WithContext(log.WithForcedDebug(r.Context()))
+}
+
 func buildLogRequest(r *http.Request) *LogRequest {
 	return &LogRequest{
 		URL:    r.URL.String(),
+		Path:   r.URL.Path,
 		Method: r.Method,
 		Header: r.Header,
 		Body:   getRequestBody(r),
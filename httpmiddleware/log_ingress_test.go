@@ -14,7 +14,11 @@ import (
 
 	"github.com/c2fo/testify/assert"
 	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
 	"github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -239,9 +243,16 @@ func TestLogMessageResponsePanic(t *testing.T) {
 	assert.Empty(t, logMessage.ReqHeader.Get("Authorization"))
 	assert.Equal(t, string(reqBody), logMessage.ReqBody)
 
-	// response
-	assert.Contains(t, logMessage.ResponseBody, "panic")
-	assert.Contains(t, string(respBody), "panic")
+	// response is generic, the panic value never reaches the client
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, `{"message":"internal server error"}`, logMessage.ResponseBody)
+	assert.Equal(t, `{"message":"internal server error"}`, string(respBody))
+
+	// panic is logged with its stack before the ingress log
+	panicEntry := hook.AllEntries()[len(hook.AllEntries())-2]
+	assert.Equal(t, "panic recovered", panicEntry.Message)
+	assert.Equal(t, hook.LastEntry().Data[log.ContextIdKey], panicEntry.Data[log.ContextIdKey])
+	assert.NotEmpty(t, panicEntry.Data[log.FieldErrorChain])
 }
 
 // TestRequestIDUnchanged to check if request id on ingress log unchanged if exists before
@@ -467,3 +478,137 @@ func TestDisableLogIngressMessage(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, hook.LastEntry())
 }
+
+func TestLogIngressMessageRedacted(t *testing.T) {
+	logger, hook := log.NewSLoggerWithTestHook("log-ingress-middleware")
+
+	config := &Config{
+		Redactor: log.NewRedactor(
+			log.RedactHeaders(log.MaskFull, "x-*-token"),
+			log.RedactFields(log.MaskHash, "name"),
+		),
+	}
+
+	mockServer := getMockServerWithConfig(logger, config)
+	defer mockServer.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, mockServer.URL+"/hello", strings.NewReader(`{"name":"muhammad-fakhri"}`))
+	req.Header.Add("X-Country", "ID")
+	req.Header.Add("X-Refresh-Token", "abcdefghijkl")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	logMessage := extractLogMessage(t, hook.LastEntry().Data)
+
+	hashed := `{"name":"` + log.MaskHash.Mask("muhammad-fakhri") + `"}`
+	assert.Equal(t, "[REDACTED]", logMessage.ReqHeader.Get("X-Refresh-Token"))
+	assert.Equal(t, "ID", logMessage.ReqHeader.Get("X-Country"))
+	assert.Equal(t, hashed, logMessage.ReqBody)
+	assert.Equal(t, hashed, logMessage.ResponseBody)
+	// the handler still gets the original body
+	assert.Equal(t, `{"name":"muhammad-fakhri"}`, string(respBody))
+}
+
+func TestLogIngressMessageRedactedTruncated(t *testing.T) {
+	logger, hook := log.NewSLoggerWithTestHook("log-ingress-middleware")
+
+	config := &Config{
+		Redactor:    log.NewRedactor(log.RedactFields(log.MaskFull, "name")),
+		MaxBodySize: 12,
+	}
+
+	mockServer := getMockServerWithConfig(logger, config)
+	defer mockServer.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, mockServer.URL+"/hello", strings.NewReader(`{"name":"muhammad-fakhri"}`))
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	logMessage := extractLogMessage(t, hook.LastEntry().Data)
+
+	// the truncated body can't be decoded, the value of the field is still masked
+	assert.Equal(t, `{"name":"[REDACTED]`, logMessage.ResponseBody)
+	assert.Equal(t, `{"name":"muhammad-fakhri"}`, string(respBody))
+}
+
+func TestLogIngressTracePropagation(t *testing.T) {
+	logger, hook := log.NewSLoggerWithTestHook("log-ingress-middleware")
+	recorder := tracetest.NewSpanRecorder()
+	config := &Config{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))}
+
+	var handlerSpan trace.SpanContext
+	handler := NewIngressLogMiddleware(logger, config).Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerSpan.TraceID().String())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hook.LastEntry().Data[log.FieldTraceID])
+	assert.Equal(t, handlerSpan.SpanID().String(), hook.LastEntry().Data[log.FieldSpanID])
+
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	assert.Equal(t, "Error", spans[0].Status().Code.String())
+}
+
+func TestLogIngressForceDebug(t *testing.T) {
+	logger, hook := log.NewSLoggerWithTestHook("log-ingress-middleware")
+	config := &Config{DisableIngressLog: true, ForceDebugHeader: "X-Force-Debug"}
+
+	handler := NewIngressLogMiddleware(logger, config).Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug(r.Context(), "handler debug")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Nil(t, hook.LastEntry())
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set("X-Force-Debug", "true")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "handler debug", hook.LastEntry().Message)
+
+	hook.Reset()
+	handler = NewIngressLogMiddleware(logger, &Config{DisableIngressLog: true}).Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug(r.Context(), "handler debug")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Nil(t, hook.LastEntry())
+}
+
+func TestLogIngressFields(t *testing.T) {
+	logger := logtest.New()
+	handler := NewIngressLogMiddleware(logger).Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/events/launch/join", nil)
+	req.Header.Set("X-Country", "id")
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("X-User-Id", "42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldType:         valueLogTypeIngress,
+		log.FieldURL:          "POST /events/launch/join",
+		log.FieldStatus:       http.StatusCreated,
+		log.ContextIdKey:      "req-1",
+		log.ContextCountryKey: "ID",
+		log.ContextUserIdKey:  "42",
+		log.ContextEventIdKey: "launch",
+	})
+}
@@ -0,0 +1,157 @@
+package httpmiddleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/muhammad-fakhri/go-libs/log"
+)
+
+const (
+	defaultPanicStatusCode  = http.StatusInternalServerError
+	defaultPanicContentType = "application/json"
+)
+
+var defaultPanicBody = []byte(`{"message":"internal server error"}`)
+
+// Middleware wraps a handler, e.g. Recoverer, Middleware is also a beego.MiddleWare
+type Middleware func(next http.Handler) http.Handler
+
+// Chain returns middleware applying middlewares in order, the first one is the outermost:
+//
+//	chain := Chain(RequestID(), ContextEnricher(logger), AccessLog(logger), Recoverer(logger))
+//	mux.Handle("/hello", chain.Then(hello))
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Then applies the middleware to next, for net/http
+func (m Middleware) Then(next http.Handler) http.Handler {
+	return m(next)
+}
+
+func (m Middleware) ThenFunc(next http.HandlerFunc) http.Handler {
+	return m(next)
+}
+
+// WithParams applies the middleware to next, for github.com/julienschmidt/httprouter, and records pattern as its route,
+// e.g. router.GET("/users/:id", m.WithParams("/users/:id", handle)). An empty pattern records no route.
+// The params are also in the request context, see httprouter.ParamsFromContext.
+func (m Middleware) WithParams(pattern string, next httprouter.Handle) httprouter.Handle {
+	handler := m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r, pattern)
+		next(w, r, httprouter.ParamsFromContext(r.Context()))
+	}))
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, ps)))
+	}
+}
+
+// RecovererConfig is the response of a recovered panic, the panic value never reaches the client
+type RecovererConfig struct {
+	StatusCode  int    // default value: 500
+	ContentType string // default value: application/json
+	Body        []byte // default value: {"message":"internal server error"}
+}
+
+// Recoverer recovers a panic of the next handler, logs it with its stack through logger and responds with config
+func Recoverer(logger log.SLogger, optionalConfig ...*RecovererConfig) Middleware {
+	config := RecovererConfig{}
+	if len(optionalConfig) > 0 && optionalConfig[0] != nil {
+		config = *optionalConfig[0]
+	}
+	if config.StatusCode == 0 {
+		config.StatusCode = defaultPanicStatusCode
+	}
+	if config.ContentType == "" {
+		config.ContentType = defaultPanicContentType
+	}
+	if config.Body == nil {
+		config.Body = defaultPanicBody
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer log.RecoverAndLog(r.Context(), logger, func(recovered interface{}) {
+				w.Header().Set("Content-Type", config.ContentType)
+				w.WriteHeader(config.StatusCode)
+				w.Write(config.Body)
+			})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestID sets a new UUID as X-Request-Id header of a request without one, and returns the header in the response
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(headerNameRequestID)
+			if requestID == "" {
+				requestID = uuid.New().String()
+				r.Header.Set(headerNameRequestID, requestID)
+			}
+			w.Header().Set(headerNameRequestID, requestID)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ContextEnricher sets the log context data of the request from its headers, unless set already,
+// and forces debug logs by config.ForceDebugHeader
+func ContextEnricher(logger log.SLogger, optionalConfig ...*Config) Middleware {
+	return NewIngressLogMiddleware(logger, optionalConfig...).enrichContext
+}
+
+// AccessLog logs the request and response as ingress log, in a server span continuing the incoming trace
+func AccessLog(logger log.SLogger, optionalConfig ...*Config) Middleware {
+	return NewIngressLogMiddleware(logger, optionalConfig...).accessLog
+}
+
+func (i *IngressLog) enrichContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, i.forceDebug(i.appendContextDataAndSetValue(r, i.logger)))
+	})
+}
+
+func (i *IngressLog) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logRequest := buildLogRequest(r)
+
+		r, span := i.startSpan(r)
+		r, route := withRouteHolder(r)
+		routeOf := func() string {
+			if route.route != "" {
+				return route.route
+			}
+			return servemuxRoute(r)
+		}
+		// the router inside records the route before the first write, the capture follows its config
+		writer := newResponseWriter(w, 0)
+		writer.bodySize = func() int {
+			return i.config.forRoute(routeOf(), logRequest.Path).responseBodySize()
+		}
+
+		startTime := time.Now()
+		defer func() {
+			logRequest.Route = routeOf()
+			i.log(r.Context(), logRequest, time.Since(startTime).Milliseconds(), startTime, writer)
+			endSpan(span, writer.statusCode)
+		}()
+
+		next.ServeHTTP(writer.Wrap(), r)
+	})
+}
+
+// chain returns the middlewares of Enforce
+func (i *IngressLog) chain() Middleware {
+	return Chain(i.enrichContext, i.accessLog, Recoverer(i.logger, i.config.Recoverer))
+}
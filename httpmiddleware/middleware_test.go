@@ -0,0 +1,118 @@
+package httpmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c2fo/testify/assert"
+	"github.com/julienschmidt/httprouter"
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(mark("first"), mark("second")).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"first", "second", "handler"}, order)
+}
+
+func TestRecoverer(t *testing.T) {
+	logger := logtest.New()
+	handler := Chain(ContextEnricher(logger), Recoverer(logger)).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret state")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `{"message":"internal server error"}`, recorder.Body.String())
+	logger.AssertLogged(t, logrus.ErrorLevel, "panic recovered", map[string]interface{}{log.ContextIdKey: "req-1"})
+}
+
+func TestRecoverer_Config(t *testing.T) {
+	handler := Recoverer(logtest.New(), &RecovererConfig{
+		StatusCode:  http.StatusServiceUnavailable,
+		ContentType: "text/plain",
+		Body:        []byte("try again later"),
+	}).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("down")
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "text/plain", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "try again later", recorder.Body.String())
+}
+
+func TestRequestID(t *testing.T) {
+	var received string
+	handler := RequestID().ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Request-Id")
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 36, len(received))
+	assert.Equal(t, received, recorder.Header().Get("X-Request-Id"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, "req-1", received)
+	assert.Equal(t, "req-1", recorder.Header().Get("X-Request-Id"))
+}
+
+func TestChain_WithParams(t *testing.T) {
+	logger := logtest.New()
+	router := httprouter.New()
+	router.GET("/users/:id", Chain(RequestID(), ContextEnricher(logger), AccessLog(logger)).WithParams("/users/:id",
+		func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			w.Write([]byte(ps.ByName("id")))
+		}))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	assert.Equal(t, "42", recorder.Body.String())
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldType:    valueLogTypeIngress,
+		log.FieldURL:     "GET /users/42",
+		log.FieldStatus:  http.StatusOK,
+		log.ContextIdKey: recorder.Header().Get("X-Request-Id"),
+	})
+}
+
+func TestLogIngressRecovererConfig(t *testing.T) {
+	logger := logtest.New()
+	handler := NewIngressLogMiddleware(logger, &Config{Recoverer: &RecovererConfig{}}).Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret state")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, `{"message":"internal server error"}`, recorder.Body.String())
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{log.FieldStatus: http.StatusInternalServerError})
+}
@@ -1,32 +1,248 @@
 package httpmiddleware
 
 import (
+	"bufio"
 	"bytes"
+	"io"
+	"net"
 	"net/http"
 )
 
+const truncatedSuffix = "...(truncated)"
+
+// LogResponseWriter records the status, the bytes written and the body up to a limit of a response.
+// Serve the handler with Wrap, so it keeps the optional interfaces of the underlying writer.
 type LogResponseWriter struct {
-	statusCode int
-	bodyBuffer *bytes.Buffer
+	statusCode  int
+	bodyBuffer  *bytes.Buffer
+	maxBodySize int
+	// bodySize resolves maxBodySize at the first write, when the route is known
+	bodySize     func() int
+	truncated    bool
+	bytesWritten int64
+	wroteHeader  bool
 	http.ResponseWriter
 }
 
+// NewResponseWriter returns writer capturing up to 64 KiB of the body
 func NewResponseWriter(w http.ResponseWriter) *LogResponseWriter {
+	return newResponseWriter(w, defaultMaxBodySize)
+}
+
+func newResponseWriter(w http.ResponseWriter, maxBodySize int) *LogResponseWriter {
 	return &LogResponseWriter{
 		ResponseWriter: w,
 		// WriteHeader(int) is not called if our response implicitly returns 200 OK, so
 		// we default to that status code.
-		statusCode: http.StatusOK,
-		bodyBuffer: &bytes.Buffer{},
+		statusCode:  http.StatusOK,
+		bodyBuffer:  &bytes.Buffer{},
+		maxBodySize: maxBodySize,
 	}
 }
 
 func (w *LogResponseWriter) WriteHeader(code int) {
-	w.statusCode = code
+	if !w.wroteHeader {
+		w.statusCode = code
+		// informational responses are followed by the final one
+		w.wroteHeader = code >= http.StatusOK || code == http.StatusSwitchingProtocols
+	}
 	w.ResponseWriter.WriteHeader(code)
 }
 
 func (w *LogResponseWriter) Write(body []byte) (int, error) {
+	w.wroteHeader = true
+	w.capture(body)
+	n, err := w.ResponseWriter.Write(body)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+func (w *LogResponseWriter) resolveBodySize() {
+	if w.bodySize != nil {
+		w.maxBodySize, w.bodySize = w.bodySize(), nil
+	}
+}
+
+func (w *LogResponseWriter) capture(body []byte) {
+	w.resolveBodySize()
+	remaining := w.maxBodySize - w.bodyBuffer.Len()
+	if len(body) > remaining {
+		body = body[:remaining]
+		w.truncated = true
+	}
 	w.bodyBuffer.Write(body)
-	return w.ResponseWriter.Write(body)
+}
+
+func (w *LogResponseWriter) Status() int {
+	return w.statusCode
+}
+
+func (w *LogResponseWriter) BytesWritten() int64 {
+	return w.bytesWritten
+}
+
+// Body returns the captured body, marked with "...(truncated)" when it was longer than the limit.
+// A truncated JSON body can't be decoded, mask it with log.Redactor.RedactBody, which masks field rules in its text.
+func (w *LogResponseWriter) Body() string {
+	if w.truncated && w.maxBodySize > 0 {
+		return w.bodyBuffer.String() + truncatedSuffix
+	}
+	return w.bodyBuffer.String()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (w *LogResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Wrap returns w implementing exactly the optional interfaces http.Flusher, http.Hijacker, http.Pusher
+// and io.ReaderFrom the underlying writer implements
+func (w *LogResponseWriter) Wrap() http.ResponseWriter {
+	_, isFlusher := w.ResponseWriter.(http.Flusher)
+	_, isHijacker := w.ResponseWriter.(http.Hijacker)
+	_, isPusher := w.ResponseWriter.(http.Pusher)
+	_, isReaderFrom := w.ResponseWriter.(io.ReaderFrom)
+
+	f, h, p, r := flusher{w}, hijacker{w}, pusher{w}, readerFrom{w}
+	switch {
+	case isFlusher && isHijacker && isPusher && isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{w, f, h, p, r}
+	case isFlusher && isHijacker && isPusher:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, f, h, p}
+	case isFlusher && isHijacker && isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{w, f, h, r}
+	case isFlusher && isPusher && isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{w, f, p, r}
+	case isHijacker && isPusher && isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{w, h, p, r}
+	case isFlusher && isHijacker:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case isFlusher && isPusher:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+			http.Pusher
+		}{w, f, p}
+	case isFlusher && isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+			io.ReaderFrom
+		}{w, f, r}
+	case isHijacker && isPusher:
+		return struct {
+			*LogResponseWriter
+			http.Hijacker
+			http.Pusher
+		}{w, h, p}
+	case isHijacker && isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{w, h, r}
+	case isPusher && isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			http.Pusher
+			io.ReaderFrom
+		}{w, p, r}
+	case isFlusher:
+		return struct {
+			*LogResponseWriter
+			http.Flusher
+		}{w, f}
+	case isHijacker:
+		return struct {
+			*LogResponseWriter
+			http.Hijacker
+		}{w, h}
+	case isPusher:
+		return struct {
+			*LogResponseWriter
+			http.Pusher
+		}{w, p}
+	case isReaderFrom:
+		return struct {
+			*LogResponseWriter
+			io.ReaderFrom
+		}{w, r}
+	}
+	return w
+}
+
+type flusher struct{ w *LogResponseWriter }
+
+func (f flusher) Flush() {
+	f.w.wroteHeader = true
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijacker records a hijacked connection without status, e.g. a websocket upgrade, as 101 Switching Protocols
+type hijacker struct{ w *LogResponseWriter }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !h.w.wroteHeader {
+		h.w.statusCode = http.StatusSwitchingProtocols
+		h.w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+type pusher struct{ w *LogResponseWriter }
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// readerFrom keeps the sendfile of the underlying writer when the body is not captured
+type readerFrom struct{ w *LogResponseWriter }
+
+func (r readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	r.w.wroteHeader = true
+	r.w.resolveBodySize()
+	if r.w.bodyBuffer.Len() < r.w.maxBodySize {
+		src = io.TeeReader(src, captureWriter{r.w})
+	}
+	n, err := r.w.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.w.bytesWritten += n
+	return n, err
+}
+
+type captureWriter struct{ w *LogResponseWriter }
+
+func (c captureWriter) Write(p []byte) (int, error) {
+	c.w.capture(p)
+	return len(p), nil
 }
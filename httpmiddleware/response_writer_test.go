@@ -0,0 +1,141 @@
+package httpmiddleware
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/assert"
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+)
+
+// fullWriter implements every optional interface
+type fullWriter struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (w *fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, _ := net.Pipe()
+	return server, nil, nil
+}
+
+func (w *fullWriter) Push(target string, opts *http.PushOptions) error {
+	return nil
+}
+
+func (w *fullWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.readFrom = true
+	return io.Copy(w.ResponseRecorder, src)
+}
+
+func TestLogResponseWriter_Wrap(t *testing.T) {
+	wrapped := NewResponseWriter(httptest.NewRecorder()).Wrap()
+	_, isFlusher := wrapped.(http.Flusher)
+	_, isHijacker := wrapped.(http.Hijacker)
+	_, isPusher := wrapped.(http.Pusher)
+	_, isReaderFrom := wrapped.(io.ReaderFrom)
+	assert.True(t, isFlusher)
+	assert.False(t, isHijacker)
+	assert.False(t, isPusher)
+	assert.False(t, isReaderFrom)
+
+	wrapped = NewResponseWriter(&fullWriter{ResponseRecorder: httptest.NewRecorder()}).Wrap()
+	_, isFlusher = wrapped.(http.Flusher)
+	_, isHijacker = wrapped.(http.Hijacker)
+	_, isPusher = wrapped.(http.Pusher)
+	_, isReaderFrom = wrapped.(io.ReaderFrom)
+	assert.True(t, isFlusher)
+	assert.True(t, isHijacker)
+	assert.True(t, isPusher)
+	assert.True(t, isReaderFrom)
+}
+
+func TestLogResponseWriter_CapturesUpToLimit(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := newResponseWriter(recorder, 5)
+	writer.Write([]byte("Hello "))
+	writer.Write([]byte("World!"))
+
+	assert.Equal(t, "Hello World!", recorder.Body.String())
+	assert.Equal(t, "Hello...(truncated)", writer.Body())
+	assert.Equal(t, int64(12), writer.BytesWritten())
+	assert.Equal(t, http.StatusOK, writer.Status())
+}
+
+func TestLogResponseWriter_ReadFrom(t *testing.T) {
+	underlying := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+	writer := newResponseWriter(underlying, 4)
+	// hide io.WriterTo of the source, so io.Copy uses ReadFrom
+	n, err := io.Copy(writer.Wrap(), struct{ io.Reader }{strings.NewReader("large download")})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(14), n)
+	assert.True(t, underlying.readFrom)
+	assert.Equal(t, "large download", underlying.Body.String())
+	assert.Equal(t, "larg...(truncated)", writer.Body())
+	assert.Equal(t, int64(14), writer.BytesWritten())
+}
+
+func TestLogResponseWriter_StatusWrittenOnce(t *testing.T) {
+	writer := NewResponseWriter(httptest.NewRecorder())
+	writer.WriteHeader(http.StatusEarlyHints)
+	writer.WriteHeader(http.StatusCreated)
+	writer.WriteHeader(http.StatusInternalServerError)
+
+	assert.Equal(t, http.StatusCreated, writer.Status())
+}
+
+func TestAccessLog_StreamsAndHijacks(t *testing.T) {
+	logger := logtest.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+	})
+	server := httptest.NewServer(Chain(ContextEnricher(logger), AccessLog(logger)).Then(mux))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "data: 1\n\n", string(body))
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		FieldResponseSize:     9,
+		log.FieldResponseBody: "data: 1\n\n",
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	resp.Body.Close()
+	// the handler may still be running after the client got the upgrade
+	upgraded := map[string]interface{}{log.FieldStatus: http.StatusSwitchingProtocols}
+	for deadline := time.Now().Add(time.Second); len(logger.Find(logrus.InfoLevel, "", upgraded)) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.AssertLogged(t, logrus.InfoLevel, "", upgraded)
+}
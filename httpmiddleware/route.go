@@ -0,0 +1,49 @@
+package httpmiddleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type routeContextKey struct{}
+
+// routeHolder is set by AccessLog, the router inside fills the route template
+type routeHolder struct {
+	route string
+}
+
+func withRouteHolder(r *http.Request) (*http.Request, *routeHolder) {
+	holder := &routeHolder{}
+	return r.WithContext(context.WithValue(r.Context(), routeContextKey{}, holder)), holder
+}
+
+// SetRoute records the route template of r for the ingress log, e.g. "/users/:id".
+// Routes of httprouter through WithParams, of http.ServeMux and of beego through beegoadapter.RecordRoute are recorded already.
+// Set it before the response is written, the route config applies from then on.
+func SetRoute(r *http.Request, route string) {
+	if holder, ok := r.Context().Value(routeContextKey{}).(*routeHolder); ok {
+		holder.route = route
+	}
+}
+
+// Route returns the route template recorded for r, empty until its router matched it
+func Route(r *http.Request) string {
+	if holder, ok := r.Context().Value(routeContextKey{}).(*routeHolder); ok {
+		return holder.route
+	}
+	return ""
+}
+
+// servemuxRoute returns the path of the http.ServeMux pattern of r, without method and host.
+// The pattern is set since go 1.22, unless GODEBUG httpmuxgo121=1.
+func servemuxRoute(r *http.Request) string {
+	pattern := requestPattern(r)
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = strings.TrimSpace(pattern[i+1:])
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
@@ -0,0 +1,11 @@
+//go:build go1.22
+// +build go1.22
+
+package httpmiddleware
+
+import "net/http"
+
+// requestPattern returns the pattern of http.ServeMux matching r
+func requestPattern(r *http.Request) string {
+	return r.Pattern
+}
@@ -0,0 +1,11 @@
+//go:build !go1.22
+// +build !go1.22
+
+package httpmiddleware
+
+import "net/http"
+
+// requestPattern is empty, http.ServeMux sets no pattern before Go 1.22
+func requestPattern(r *http.Request) string {
+	return ""
+}
@@ -0,0 +1,25 @@
+//go:build go1.22
+// +build go1.22
+
+//go:debug httpmuxgo121=0
+
+package httpmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAccessLog_ServeMuxRoute(t *testing.T) {
+	logger := logtest.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	AccessLog(logger).Then(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{FieldRoute: "/orders/{id}"})
+}
@@ -0,0 +1,90 @@
+package httpmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c2fo/testify/assert"
+	"github.com/julienschmidt/httprouter"
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/muhammad-fakhri/go-libs/log/logtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAccessLog_Route(t *testing.T) {
+	logger := logtest.New()
+	router := httprouter.New()
+	router.GET("/users/new/:id", AccessLog(logger).WithParams("/users/new/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		assert.Equal(t, "/users/new/:id", Route(r))
+	}))
+
+	// a param value equal to a static segment doesn't change the route
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/new/new?token=secret", nil))
+
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		log.FieldURL: "GET /users/new/new?token=[REDACTED]",
+		FieldRoute:   "/users/new/:id",
+	})
+}
+
+func TestAccessLog_SetRoute(t *testing.T) {
+	logger := logtest.New()
+	handler := AccessLog(logger).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r, "/orders/{id}")
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{FieldRoute: "/orders/{id}"})
+}
+
+func TestAccessLog_RouteConfig(t *testing.T) {
+	logger := logtest.New()
+	router := httprouter.New()
+	middleware := AccessLog(logger, &Config{Routes: map[string]*RouteConfig{
+		"/health":    {DisableIngressLog: true},
+		"/login/:id": {ExcludeOpt: &ExcludeOption{RequestBody: true, ResponseBody: true}},
+	}})
+	handle := func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Write([]byte("password"))
+	}
+	router.GET("/health", middleware.WithParams("/health", handle))
+	router.GET("/login/:id", middleware.WithParams("/login/:id", handle))
+	router.GET("/users/:id", middleware.WithParams("/users/:id", handle))
+
+	for _, path := range []string{"/health", "/login/1", "/users/1"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 0, len(logger.Find(logrus.InfoLevel, "", map[string]interface{}{log.FieldURL: "GET /health"})))
+	login := logger.Find(logrus.InfoLevel, "", map[string]interface{}{FieldRoute: "/login/:id"})
+	if assert.Equal(t, 1, len(login)) {
+		_, logged := login[0].Field(log.FieldResponseBody)
+		assert.False(t, logged)
+	}
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		FieldRoute:            "/users/:id",
+		log.FieldResponseBody: "password",
+	})
+}
+
+func TestAccessLog_RouteConfigCapture(t *testing.T) {
+	logger := logtest.New()
+	router := httprouter.New()
+	middleware := AccessLog(logger, &Config{
+		ExcludeOpt: &ExcludeOption{ResponseBody: true},
+		Routes:     map[string]*RouteConfig{"/users/:id": {ExcludeOpt: &ExcludeOption{}}},
+	})
+	router.GET("/users/:id", middleware.WithParams("/users/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Write([]byte("user " + ps.ByName("id")))
+	}))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	// the response body is captured by the config of the route, known only once the router matched it
+	logger.AssertLogged(t, logrus.InfoLevel, "", map[string]interface{}{
+		FieldRoute:            "/users/:id",
+		log.FieldResponseBody: "user 1",
+	})
+}
@@ -0,0 +1,38 @@
+package httpmiddleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/muhammad-fakhri/go-libs/httpmiddleware"
+
+// propagator reads W3C traceparent, tracestate and baggage headers
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+func (c *Config) tracer() trace.Tracer {
+	if c.TracerProvider == nil {
+		return otel.Tracer(tracerName)
+	}
+	return c.TracerProvider.Tracer(tracerName)
+}
+
+// startSpan continues the trace of the incoming traceparent header, or starts a new one
+func (i *IngressLog) startSpan(r *http.Request) (*http.Request, trace.Span) {
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := i.config.tracer().Start(ctx, "HTTP "+r.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", "", r)...),
+	)
+	return r.WithContext(ctx), span
+}
+
+func endSpan(span trace.Span, statusCode int) {
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(statusCode)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(statusCode, trace.SpanKindServer))
+	span.End()
+}
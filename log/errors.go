@@ -0,0 +1,165 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	FieldError      = "error"
+	FieldErrorChain = "error.chain"
+
+	// maxErrorChainLength bounds the causes logged for a chain, guarding against cyclic Unwrap
+	maxErrorChainLength = 32
+	maxStackDepth       = 32
+
+	panicMessage = "panic recovered"
+)
+
+// ErrorCause is one error of the chain logged under "error.chain" by ErrorErr
+type ErrorCause struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	// Stack is set for errors created by WrapErr and RecoverAndLog, one "function file:line" per frame
+	Stack []string `json:"stack,omitempty"`
+}
+
+type stackError struct {
+	msg   string
+	err   error
+	stack []uintptr
+}
+
+// WrapErr annotates err with msg and the stack of its caller, the stack is logged by ErrorErr.
+// errors.Is and errors.As still see err. WrapErr returns nil when err is nil.
+func WrapErr(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{msg: msg, err: err, stack: callers(3)}
+}
+
+func (e *stackError) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+// StackTrace returns frames of the stack captured by WrapErr
+func (e *stackError) StackTrace() []string {
+	frames := runtime.CallersFrames(e.stack)
+
+	var trace []string
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			trace = append(trace, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return trace
+}
+
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	return pcs[:runtime.Callers(skip, pcs)]
+}
+
+// ErrorChain walks err through Unwrap() error and Unwrap() []error, e.g. errors.Join, depth first
+func ErrorChain(err error) []ErrorCause {
+	var chain []ErrorCause
+
+	var walk func(err error)
+	walk = func(err error) {
+		if err == nil || len(chain) >= maxErrorChainLength {
+			return
+		}
+
+		cause := ErrorCause{Message: err.Error(), Type: fmt.Sprintf("%T", err)}
+		if stackErr, ok := err.(*stackError); ok {
+			cause.Stack = stackErr.StackTrace()
+		}
+		chain = append(chain, cause)
+
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				walk(e)
+			}
+			return
+		}
+		walk(errors.Unwrap(err))
+	}
+	walk(err)
+
+	return chain
+}
+
+// ErrorErr logs msg at error level with err under "error" and its causes under "error.chain"
+func (l *SLog) ErrorErr(ctx context.Context, err error, msg string) {
+	entry := l.entryFor(ctx, log.ErrorLevel)
+	if entry == nil || !l.sampled(ctx, log.ErrorLevel, msg) {
+		return
+	}
+
+	data := getDefaultData(ctx, log.ErrorLevel)
+	if err != nil {
+		data[FieldError] = err.Error()
+		data[FieldErrorChain] = ErrorChain(err)
+	}
+	entry.WithFields(log.Fields(data)).Error(msg)
+}
+
+// LogErr logs msg with err and its causes through logger.ErrorErr, or through logger.ErrorMap when logger is not an ErrorLogger
+func LogErr(ctx context.Context, logger SLogger, err error, msg string) {
+	if errorLogger, ok := logger.(ErrorLogger); ok {
+		errorLogger.ErrorErr(ctx, err, msg)
+		return
+	}
+
+	dataMap := map[string]interface{}{}
+	if err != nil {
+		dataMap[FieldError] = err.Error()
+		dataMap[FieldErrorChain] = ErrorChain(err)
+	}
+	logger.ErrorMap(ctx, dataMap, msg)
+}
+
+// RecoverAndLog recovers a panic and logs it with the panicking stack through LogErr, without sampling,
+// then calls onPanic with the recovered value. http.ErrAbortHandler is panicked again for net/http to abort the response.
+// It must be deferred directly:
+//
+//	defer log.RecoverAndLog(ctx, logger, func(recovered interface{}) {
+//		w.WriteHeader(http.StatusInternalServerError)
+//	})
+func RecoverAndLog(ctx context.Context, logger SLogger, onPanic ...func(recovered interface{})) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("%v", recovered)
+	}
+	// skip runtime.Callers, callers, RecoverAndLog and runtime.gopanic
+	LogErr(withoutSampling(ctx), logger, &stackError{msg: panicMessage, err: err, stack: callers(4)}, panicMessage)
+
+	for _, fn := range onPanic {
+		fn(recovered)
+	}
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/assert"
+	log "github.com/sirupsen/logrus"
+)
+
+type multiError []error
+
+func (m multiError) Error() string   { return fmt.Sprintf("%d errors", len(m)) }
+func (m multiError) Unwrap() []error { return m }
+
+func TestWrapErr(t *testing.T) {
+	assert.Nil(t, WrapErr(nil, "read config"))
+
+	err := WrapErr(io.EOF, "read config")
+	assert.Equal(t, "read config: EOF", err.Error())
+	assert.True(t, errors.Is(err, io.EOF))
+
+	stack := err.(*stackError).StackTrace()
+	assert.True(t, len(stack) > 0)
+	assert.True(t, strings.HasPrefix(stack[0], "github.com/muhammad-fakhri/go-libs/log.TestWrapErr "))
+}
+
+func TestErrorChain(t *testing.T) {
+	assert.Nil(t, ErrorChain(nil))
+
+	err := fmt.Errorf("load user: %w", multiError{WrapErr(io.EOF, "read"), errors.New("timeout")})
+	chain := ErrorChain(err)
+
+	assert.Equal(t, 5, len(chain))
+	assert.Equal(t, "load user: 2 errors", chain[0].Message)
+	assert.Equal(t, "*fmt.wrapError", chain[0].Type)
+	assert.Equal(t, "log.multiError", chain[1].Type)
+	assert.Equal(t, "read: EOF", chain[2].Message)
+	assert.True(t, len(chain[2].Stack) > 0)
+	assert.Equal(t, "EOF", chain[3].Message)
+	assert.Nil(t, chain[3].Stack)
+	assert.Equal(t, "timeout", chain[4].Message)
+}
+
+func TestErrorErr(t *testing.T) {
+	slogger, hook := NewSLoggerWithTestHook("svc")
+	logger := slogger.(ErrorLogger)
+	ctx := slogger.BuildContextDataAndSetValue("ID", "ctx-1")
+
+	logger.ErrorErr(ctx, fmt.Errorf("save order: %w", io.EOF), "checkout failed")
+
+	entry := hook.LastEntry()
+	assert.Equal(t, "checkout failed", entry.Message)
+	assert.Equal(t, "save order: EOF", entry.Data[FieldError])
+	assert.Equal(t, "ctx-1", entry.Data[ContextIdKey])
+	assert.Equal(t, 2, len(entry.Data[FieldErrorChain].([]ErrorCause)))
+
+	logger.ErrorErr(ctx, nil, "no error")
+	assert.Nil(t, hook.LastEntry().Data[FieldError])
+}
+
+// mapLogger implements SLogger without ErrorErr
+type mapLogger struct {
+	SLogger
+	dataMap map[string]interface{}
+	args    []interface{}
+}
+
+func (l *mapLogger) ErrorMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{}) {
+	l.dataMap, l.args = dataMap, args
+}
+
+func TestLogErr(t *testing.T) {
+	logger := &mapLogger{}
+	LogErr(context.Background(), logger, fmt.Errorf("save order: %w", io.EOF), "checkout failed")
+
+	assert.Equal(t, []interface{}{"checkout failed"}, logger.args)
+	assert.Equal(t, "save order: EOF", logger.dataMap[FieldError])
+	assert.Equal(t, 2, len(logger.dataMap[FieldErrorChain].([]ErrorCause)))
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	logger, hook := NewSLoggerWithTestHook("svc")
+
+	var recovered interface{}
+	func() {
+		defer RecoverAndLog(context.Background(), logger, func(r interface{}) { recovered = r })
+		panicking()
+	}()
+
+	assert.Equal(t, "boom", recovered)
+	entry := hook.LastEntry()
+	assert.Equal(t, panicMessage, entry.Message)
+	assert.Equal(t, "panic recovered: boom", entry.Data[FieldError])
+
+	chain := entry.Data[FieldErrorChain].([]ErrorCause)
+	assert.True(t, strings.HasPrefix(chain[0].Stack[0], "github.com/muhammad-fakhri/go-libs/log.panicking "))
+
+	// nothing is logged without a panic
+	hook.Reset()
+	func() {
+		defer RecoverAndLog(context.Background(), logger)
+	}()
+	assert.Nil(t, hook.LastEntry())
+}
+
+func TestRecoverAndLogAbortHandler(t *testing.T) {
+	logger, hook := NewSLoggerWithTestHook("svc")
+
+	defer func() {
+		assert.Equal(t, http.ErrAbortHandler, recover())
+		assert.Nil(t, hook.LastEntry())
+	}()
+	func() {
+		defer RecoverAndLog(context.Background(), logger, func(r interface{}) { t.Error("onPanic called") })
+		panic(http.ErrAbortHandler)
+	}()
+}
+
+func TestRecoverAndLogNotSampled(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewSLogger("svc", WithSink(sink, log.InfoLevel), WithSampling(SamplingConfig{
+		Tick:   time.Hour,
+		Levels: map[log.Level]SamplingPolicy{log.ErrorLevel: {First: 1}},
+	}))
+
+	for i := 0; i < 3; i++ {
+		func() {
+			defer RecoverAndLog(context.Background(), logger)
+			panicking()
+		}()
+	}
+	assert.Equal(t, 3, sink.len())
+}
+
+func panicking() {
+	panic("boom")
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+	"time"
+)
+
+// Field is a typed key value pair added to every entry logged with the context, see WithFields
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func Str(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration is logged as text, e.g. "1.5s"
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value.String()}
+}
+
+// Time is logged as RFC3339 with nanoseconds
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value.Format(time.RFC3339Nano)}
+}
+
+// Err is logged under "error" key, nil error is logged as null
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: nil}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+type contextFieldsKeyType struct{}
+
+var contextFieldsKey = contextFieldsKeyType{}
+
+// WithFields returns context carrying fields merged over the fields already in ctx, the later value wins on the same key.
+// Stored fields are never modified, so contexts forked to other goroutines can be extended independently.
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	parent, _ := ctx.Value(contextFieldsKey).(map[string]interface{})
+
+	merged := make(map[string]interface{}, len(parent)+len(fields))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for _, field := range fields {
+		merged[field.Key] = field.Value
+	}
+
+	return context.WithValue(ctx, contextFieldsKey, merged)
+}
+
+// ContextFields returns copy of the fields added to ctx with WithFields
+func ContextFields(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	stored, ok := ctx.Value(contextFieldsKey).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(stored))
+	for key, value := range stored {
+		result[key] = value
+	}
+	return result
+}
+
+// WithContextData returns context carrying data merged over the context data already in ctx, see ContextDataMapKey.
+// The existing map is copied rather than modified.
+func WithContextData(ctx context.Context, data map[string]string) context.Context {
+	parent, _ := ctx.Value(ContextDataMapKey).(map[string]string)
+
+	merged := make(map[string]string, len(parent)+len(data))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range data {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, ContextDataMapKey, merged)
+}
+
+func (f fields) getFieldsFromBag(ctx context.Context) {
+	stored, _ := ctx.Value(contextFieldsKey).(map[string]interface{})
+	for key, value := range stored {
+		f[key] = value
+	}
+}
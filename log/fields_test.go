@@ -0,0 +1,73 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/assert"
+)
+
+func TestWithFields(t *testing.T) {
+	logger, hook := NewSLoggerWithTestHook("svc")
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+
+	ctx = WithFields(ctx, Int("order_id", 5), Str("status", "new"), Bool("paid", false))
+	ctx = WithFields(ctx, Str("status", "paid"), Bool("paid", true), Float64("amount", 10.5),
+		Duration("elapsed", 1500*time.Millisecond), Err(errors.New("boom")))
+
+	logger.Warnf(ctx, "order %d", 5)
+	data := hook.LastEntry().Data
+	assert.Equal(t, 5, data["order_id"])
+	assert.Equal(t, "paid", data["status"])
+	assert.Equal(t, true, data["paid"])
+	assert.Equal(t, 10.5, data["amount"])
+	assert.Equal(t, "1.5s", data["elapsed"])
+	assert.Equal(t, "boom", data["error"])
+	assert.Equal(t, "ctx-1", data[ContextIdKey])
+
+	logger.InfoMap(ctx, map[string]interface{}{"status": "overridden"})
+	assert.Equal(t, "overridden", hook.LastEntry().Data["status"])
+}
+
+func TestWithFieldsImmutable(t *testing.T) {
+	parent := WithFields(context.Background(), Int("shared", 1))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := WithFields(parent, Int("shared", i), Int(fmt.Sprintf("child_%d", i), i))
+			assert.Equal(t, i, ContextFields(child)["shared"])
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]interface{}{"shared": 1}, ContextFields(parent))
+
+	copied := ContextFields(parent)
+	copied["shared"] = 2
+	assert.Equal(t, 1, ContextFields(parent)["shared"])
+	assert.Nil(t, ContextFields(context.Background()))
+}
+
+func TestSetContextDataMerge(t *testing.T) {
+	logger, hook := NewSLoggerWithTestHook("svc")
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+
+	merged := logger.SetContextData(ctx, &CommonFields{UserID: "42", Fields: []Field{Int("order_id", 5)}})
+
+	logger.Info(merged, "hello")
+	data := hook.LastEntry().Data
+	assert.Equal(t, "ID", data[ContextCountryKey])
+	assert.Equal(t, "ctx-1", data[ContextIdKey])
+	assert.Equal(t, "42", data[ContextUserIdKey])
+	assert.Equal(t, 5, data["order_id"])
+
+	// parent context is left untouched
+	assert.Equal(t, "", ctx.Value(ContextDataMapKey).(map[string]string)[ContextUserIdKey])
+}
@@ -6,6 +6,7 @@ require (
 	github.com/c2fo/testify v0.0.0-20150827203832-fba96363964a
 	github.com/golang/mock v1.4.3
 	github.com/sirupsen/logrus v1.4.2
+	go.opentelemetry.io/otel/trace v1.7.0
 )
 
 require golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd // indirect
@@ -0,0 +1,236 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// callerLevels keeps level overrides by caller, the zero value has no override
+type callerLevels struct {
+	// count is accessed atomically, keep it first for 64-bit alignment
+	count int64
+
+	mu     sync.RWMutex
+	levels map[string]log.Level
+}
+
+func (c *callerLevels) set(caller string, level log.Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.levels == nil {
+		c.levels = make(map[string]log.Level)
+	}
+	c.levels[caller] = level
+	atomic.StoreInt64(&c.count, int64(len(c.levels)))
+}
+
+func (c *callerLevels) unset(caller string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.levels, caller)
+	atomic.StoreInt64(&c.count, int64(len(c.levels)))
+}
+
+func (c *callerLevels) all() map[string]log.Level {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]log.Level, len(c.levels))
+	for caller, level := range c.levels {
+		result[caller] = level
+	}
+	return result
+}
+
+func (c *callerLevels) empty() bool {
+	return atomic.LoadInt64(&c.count) == 0
+}
+
+// match returns level of the longest override matching function, e.g. "github.com/org/svc/repository.(*Repo).Get"
+// is matched by itself, "github.com/org/svc/repository" and "github.com/org/svc"
+func (c *callerLevels) match(function string) (log.Level, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		level   log.Level
+		longest = -1
+	)
+	for caller, l := range c.levels {
+		if len(caller) <= longest || !strings.HasPrefix(function, caller) {
+			continue
+		}
+		if len(function) > len(caller) && function[len(caller)] != '.' && function[len(caller)] != '/' {
+			continue
+		}
+		level, longest = l, len(caller)
+	}
+	return level, longest >= 0
+}
+
+// entryFor returns the entry used to log at level, nil when level is disabled for ctx and caller.
+// An entry enabled only by forced debug or a caller level is logged through the overridden logger and marked, see isOverridden.
+func (l *SLog) entryFor(ctx context.Context, level log.Level) *log.Entry {
+	enabled := l.entry.Logger.IsLevelEnabled(level)
+
+	overridden := false
+	if level <= log.DebugLevel && IsForcedDebug(ctx) {
+		enabled, overridden = true, true
+	} else if !l.callers.empty() {
+		if frame := getFrame(); frame != nil {
+			if callerLevel, ok := l.callers.match(frame.Function); ok {
+				enabled = level <= callerLevel
+				overridden = enabled
+			}
+		}
+	}
+
+	if !enabled {
+		return nil
+	}
+	if overridden {
+		return l.overridden.WithContext(context.WithValue(ctx, overriddenKey, true))
+	}
+	return l.entry
+}
+
+type overriddenKeyType struct{}
+
+var overriddenKey = overriddenKeyType{}
+
+// isOverridden reports whether entry is enabled by forced debug or a caller level, it is written to every sink
+func isOverridden(entry *log.Entry) bool {
+	if entry.Context == nil {
+		return false
+	}
+	overridden, _ := entry.Context.Value(overriddenKey).(bool)
+	return overridden
+}
+
+// newOverriddenEntry returns entry logging every level through the hooks, formatter and output of entry's logger,
+// so entries enabled by forced debug or a caller level never change the level of the logger
+func newOverriddenEntry(entry *log.Entry) *log.Entry {
+	base := entry.Logger
+	overridden := &log.Logger{
+		Out:          &forwardWriter{logger: base},
+		Hooks:        base.Hooks,
+		Formatter:    forwardFormatter{logger: base},
+		ReportCaller: base.ReportCaller,
+		Level:        log.TraceLevel,
+	}
+	return log.NewEntry(overridden).WithFields(entry.Data)
+}
+
+// forwardFormatter formats with the current formatter of logger, e.g. after SetFormatter
+type forwardFormatter struct {
+	logger *log.Logger
+}
+
+func (f forwardFormatter) Format(entry *log.Entry) ([]byte, error) {
+	return f.logger.Formatter.Format(entry)
+}
+
+// forwardWriter writes to the current output of logger, e.g. after SetOutput.
+// Overridden entries are serialized by its lock, sinks are serialized by sinkHook.
+type forwardWriter struct {
+	mu     sync.Mutex
+	logger *log.Logger
+}
+
+func (w *forwardWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.logger.Out.Write(p)
+}
+
+func (l *SLog) GetLevel() log.Level {
+	return l.entry.Logger.GetLevel()
+}
+
+// SetCallerLevel overrides level of entries logged from caller, a package path or a function name prefix
+func (l *SLog) SetCallerLevel(caller string, level log.Level) {
+	l.callers.set(caller, level)
+}
+
+func (l *SLog) UnsetCallerLevel(caller string) {
+	l.callers.unset(caller)
+}
+
+func (l *SLog) CallerLevels() map[string]log.Level {
+	return l.callers.all()
+}
+
+type forcedDebugKeyType struct{}
+
+var forcedDebugKey = forcedDebugKeyType{}
+
+// WithForcedDebug returns context whose entries are logged down to debug level regardless of logger and caller levels
+func WithForcedDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcedDebugKey, true)
+}
+
+func IsForcedDebug(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	forced, _ := ctx.Value(forcedDebugKey).(bool)
+	return forced
+}
+
+type levelState struct {
+	Level   string            `json:"level"`
+	Callers map[string]string `json:"callers"`
+}
+
+// NewLevelHandler returns admin handler to read and change levels of logger without a restart:
+//
+//	GET    returns {"level":"info","callers":{"github.com/org/svc/repository":"debug"}}
+//	PUT    ?level=debug sets logger level, ?level=debug&caller=github.com/org/svc/repository sets caller level
+//	DELETE ?caller=github.com/org/svc/repository removes caller level
+func NewLevelHandler(logger LevelController) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller := r.FormValue("caller")
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			level, err := log.ParseLevel(r.FormValue("level"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if caller == "" {
+				logger.SetLevel(level)
+			} else {
+				logger.SetCallerLevel(caller, level)
+			}
+		case http.MethodDelete:
+			if caller == "" {
+				http.Error(w, "missing caller", http.StatusBadRequest)
+				return
+			}
+			logger.UnsetCallerLevel(caller)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		state := levelState{Level: logger.GetLevel().String(), Callers: map[string]string{}}
+		for c, level := range logger.CallerLevels() {
+			state.Callers[c] = level.String()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state)
+	})
+}
@@ -0,0 +1,42 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HandleLevelSignals switches logger to debug level on SIGUSR1 and back to its current level on SIGUSR2.
+// Call stop to unregister the handler.
+func HandleLevelSignals(logger LevelController) (stop func()) {
+	original := logger.GetLevel()
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGUSR1 {
+					logger.SetLevel(log.DebugLevel)
+				} else {
+					logger.SetLevel(original)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/assert"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestCallerLevelsMatch(t *testing.T) {
+	c := &callerLevels{}
+	_, ok := c.match("github.com/org/svc/repository.(*Repo).Get")
+	assert.False(t, ok)
+
+	c.set("github.com/org/svc", log.WarnLevel)
+	c.set("github.com/org/svc/repository", log.DebugLevel)
+	c.set("github.com/org/svc/repository.(*Repo).Get", log.ErrorLevel)
+
+	level, ok := c.match("github.com/org/svc/repository.(*Repo).Get")
+	assert.True(t, ok)
+	assert.Equal(t, log.ErrorLevel, level)
+
+	level, _ = c.match("github.com/org/svc/repository.(*Repo).List")
+	assert.Equal(t, log.DebugLevel, level)
+
+	level, _ = c.match("github.com/org/svc/handler.Get")
+	assert.Equal(t, log.WarnLevel, level)
+
+	_, ok = c.match("github.com/org/svcx.Get")
+	assert.False(t, ok)
+
+	c.unset("github.com/org/svc")
+	_, ok = c.match("github.com/org/svc/handler.Get")
+	assert.False(t, ok)
+	assert.Equal(t, 2, len(c.all()))
+}
+
+func TestCallerLevelOverride(t *testing.T) {
+	slogger, hook := NewSLoggerWithTestHook("svc")
+	logger := slogger.(*SLog)
+	ctx := context.Background()
+
+	// entries logged by tests of this package are attributed to the testing package
+	logger.SetCallerLevel("testing", log.DebugLevel)
+	logger.Debug(ctx, "debug from override")
+	assert.Equal(t, "debug from override", hook.LastEntry().Message)
+	assert.Equal(t, log.InfoLevel, logger.GetLevel())
+
+	logger.SetCallerLevel("testing", log.ErrorLevel)
+	logger.Warn(ctx, "dropped")
+	assert.Equal(t, "debug from override", hook.LastEntry().Message)
+
+	logger.UnsetCallerLevel("testing")
+	logger.Warn(ctx, "warn")
+	assert.Equal(t, "warn", hook.LastEntry().Message)
+}
+
+func TestForcedDebug(t *testing.T) {
+	logger, hook := NewSLoggerWithTestHook("svc")
+	logger.SetLevel(log.ErrorLevel)
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+
+	logger.Debugf(ctx, "dropped")
+	assert.Nil(t, hook.LastEntry())
+
+	logger.Debugf(WithForcedDebug(ctx), "forced %d", 1)
+	assert.Equal(t, "forced 1", hook.LastEntry().Message)
+	assert.Equal(t, log.DebugLevel, hook.LastEntry().Level)
+	assert.Equal(t, "ctx-1", hook.LastEntry().Data[ContextIdKey])
+	assert.False(t, IsForcedDebug(ctx))
+}
+
+func TestLevelHandler(t *testing.T) {
+	logger := NewSLogger("svc").(LevelController)
+	handler := NewLevelHandler(logger)
+
+	serve := func(method, target string) (int, levelState) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		state := levelState{}
+		json.Unmarshal(rec.Body.Bytes(), &state)
+		return rec.Code, state
+	}
+
+	code, state := serve(http.MethodPut, "/log/level?level=debug")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", state.Level)
+	assert.Equal(t, log.DebugLevel, logger.GetLevel())
+
+	_, state = serve(http.MethodPut, "/log/level?level=warn&caller=github.com/org/svc")
+	assert.Equal(t, map[string]string{"github.com/org/svc": "warning"}, state.Callers)
+
+	_, state = serve(http.MethodDelete, "/log/level?caller=github.com/org/svc")
+	assert.Equal(t, map[string]string{}, state.Callers)
+
+	code, _ = serve(http.MethodPut, "/log/level?level=loud")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = serve(http.MethodPatch, "/log/level")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestHandleLevelSignals(t *testing.T) {
+	logger := NewSLogger("svc").(LevelController)
+	stop := HandleLevelSignals(logger)
+	defer stop()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.True(t, waitLevel(logger, log.DebugLevel))
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	assert.True(t, waitLevel(logger, log.InfoLevel))
+}
+
+func waitLevel(logger LevelController, level log.Level) bool {
+	for i := 0; i < 100; i++ {
+		if logger.GetLevel() == level {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
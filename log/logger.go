@@ -4,9 +4,12 @@ package log
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -37,6 +40,34 @@ type SLogger interface {
 	SetLevel(level log.Level)
 }
 
+// ErrorLogger is implemented by loggers of NewSLogger and logtest, see LogErr for other loggers
+type ErrorLogger interface {
+	// ErrorErr logs msg with err and its causes, see ErrorChain and WrapErr
+	ErrorErr(ctx context.Context, err error, msg string)
+}
+
+// LevelController changes levels at runtime, implemented by NewSLogger and logtest loggers, check it by type assertion
+type LevelController interface {
+	SetLevel(level log.Level)
+	GetLevel() log.Level
+	// SetCallerLevel overrides level of entries logged from caller, a package path or a function name prefix
+	SetCallerLevel(caller string, level log.Level)
+	UnsetCallerLevel(caller string)
+	CallerLevels() map[string]log.Level
+}
+
+// Flusher is implemented by loggers buffering entries, e.g. NewSLogger with WithAsync, check it by type assertion:
+//
+//	if flusher, ok := logger.(log.Flusher); ok {
+//		defer flusher.Close()
+//	}
+type Flusher interface {
+	// Flush waits until buffered entries are written to every sink
+	Flush() error
+	// Close flushes and closes every sink
+	Close() error
+}
+
 // safe typing https://golang.org/pkg/context/#WithValue
 type contextDataMapKeyType string
 
@@ -53,7 +84,13 @@ var (
 )
 
 type SLog struct {
-	entry *log.Entry
+	entry   *log.Entry
+	sinks   *sinkHook
+	sampler *sampler
+	callers callerLevels
+
+	// overridden logs entries enabled by forced debug or a caller level, see entryFor
+	overridden *log.Entry
 }
 
 // context key data added to map
@@ -70,18 +107,52 @@ const (
 	minimumCallerDepth int = 1
 )
 
-func NewSLogger(service string) SLogger {
-	entry, _ := getEntryAndLogger(service)
-	return &SLog{entry}
+// NewSLogger returns logger writing JSON entries to stderr, or to the sinks given by WithSink
+func NewSLogger(service string, options ...Option) SLogger {
+	c := &config{}
+	for _, o := range options {
+		o(c)
+	}
+
+	entry, logger, sinks := getEntryAndLogger(service, c)
+	l := &SLog{entry: entry, sinks: sinks, overridden: newOverriddenEntry(entry)}
+	if c.sampling != nil {
+		l.sampler = newSampler(*c.sampling)
+		if c.sampling.Dedup {
+			go l.summarize()
+		}
+	}
+	logger.ExitFunc = l.exit
+	return l
+}
+
+// osExit is replaced by tests
+var osExit = os.Exit
+
+// exit closes the sinks before Fatal exits, so the fatal entry and the entries buffered before it are written
+func (l *SLog) exit(code int) {
+	l.Close()
+	osExit(code)
 }
 
-func getEntryAndLogger(service string) (*log.Entry, *log.Logger) {
+func getEntryAndLogger(service string, c *config) (*log.Entry, *log.Logger, *sinkHook) {
 	logger := log.New()
-
 	logger.SetFormatter(&log.JSONFormatter{})
+
+	var sinks *sinkHook
+	if len(c.sinks) > 0 {
+		sinks = newSinkHook(c)
+		logger.SetFormatter(nopFormatter{})
+		logger.SetOutput(ioutil.Discard)
+		logger.AddHook(sinks)
+		if level := sinks.maxLevel(); level > logger.GetLevel() {
+			logger.SetLevel(level)
+		}
+	}
+
 	entry := log.NewEntry(logger)
 	entry = entry.WithField("service", service)
-	return entry, logger
+	return entry, logger, sinks
 }
 
 func (l *SLog) BuildContextDataAndSetValue(country string, contextId string) (ctx context.Context) {
@@ -111,19 +182,35 @@ func (l *SLog) GetEntry() *log.Entry {
 }
 
 func (l *SLog) Infof(ctx context.Context, message string, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.InfoLevel))).Infof(message, args...)
+	entry := l.entryFor(ctx, log.InfoLevel)
+	if entry == nil || !l.sampled(ctx, log.InfoLevel, message) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.InfoLevel))).Infof(message, args...)
 }
 
 func (l *SLog) Errorf(ctx context.Context, message string, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.ErrorLevel))).Errorf(message, args...)
+	entry := l.entryFor(ctx, log.ErrorLevel)
+	if entry == nil || !l.sampled(ctx, log.ErrorLevel, message) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.ErrorLevel))).Errorf(message, args...)
 }
 
 func (l *SLog) Warnf(ctx context.Context, message string, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.WarnLevel))).Warnf(message, args...)
+	entry := l.entryFor(ctx, log.WarnLevel)
+	if entry == nil || !l.sampled(ctx, log.WarnLevel, message) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.WarnLevel))).Warnf(message, args...)
 }
 
 func (l *SLog) Debugf(ctx context.Context, message string, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.DebugLevel))).Debugf(message, args...)
+	entry := l.entryFor(ctx, log.DebugLevel)
+	if entry == nil || !l.sampled(ctx, log.DebugLevel, message) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.DebugLevel))).Debugf(message, args...)
 }
 
 func (l *SLog) Fatalf(ctx context.Context, message string, args ...interface{}) {
@@ -131,19 +218,35 @@ func (l *SLog) Fatalf(ctx context.Context, message string, args ...interface{})
 }
 
 func (l *SLog) Info(ctx context.Context, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.InfoLevel))).Info(args...)
+	entry := l.entryFor(ctx, log.InfoLevel)
+	if entry == nil || !l.sampled(ctx, log.InfoLevel, argsTemplate(args)) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.InfoLevel))).Info(args...)
 }
 
 func (l *SLog) Error(ctx context.Context, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.ErrorLevel))).Error(args...)
+	entry := l.entryFor(ctx, log.ErrorLevel)
+	if entry == nil || !l.sampled(ctx, log.ErrorLevel, argsTemplate(args)) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.ErrorLevel))).Error(args...)
 }
 
 func (l *SLog) Warn(ctx context.Context, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.WarnLevel))).Warn(args...)
+	entry := l.entryFor(ctx, log.WarnLevel)
+	if entry == nil || !l.sampled(ctx, log.WarnLevel, argsTemplate(args)) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.WarnLevel))).Warn(args...)
 }
 
 func (l *SLog) Debug(ctx context.Context, args ...interface{}) {
-	l.entry.WithFields(log.Fields(getDefaultData(ctx, log.DebugLevel))).Debug(args...)
+	entry := l.entryFor(ctx, log.DebugLevel)
+	if entry == nil || !l.sampled(ctx, log.DebugLevel, argsTemplate(args)) {
+		return
+	}
+	entry.WithFields(log.Fields(getDefaultData(ctx, log.DebugLevel))).Debug(args...)
 }
 
 func (l *SLog) Fatal(ctx context.Context, args ...interface{}) {
@@ -151,21 +254,50 @@ func (l *SLog) Fatal(ctx context.Context, args ...interface{}) {
 }
 
 func (l *SLog) InfoMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{}) {
+	entry := l.entryFor(ctx, log.InfoLevel)
+	if entry == nil || !l.sampled(ctx, log.InfoLevel, mapTemplate(dataMap, args)) {
+		return
+	}
 	data := getDefaultData(ctx, log.InfoLevel)
 	data.getFieldsFromDataMap(dataMap)
-	l.entry.WithFields(log.Fields(data)).Info(args...)
+	entry.WithFields(log.Fields(data)).Info(args...)
 }
 
 func (l *SLog) ErrorMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{}) {
+	entry := l.entryFor(ctx, log.ErrorLevel)
+	if entry == nil || !l.sampled(ctx, log.ErrorLevel, mapTemplate(dataMap, args)) {
+		return
+	}
 	data := getDefaultData(ctx, log.ErrorLevel)
 	data.getFieldsFromDataMap(dataMap)
-	l.entry.WithFields(log.Fields(data)).Error(args...)
+	entry.WithFields(log.Fields(data)).Error(args...)
 }
 
 func (l *SLog) SetLevel(level log.Level) {
 	l.entry.Logger.SetLevel(level)
 }
 
+func (l *SLog) Flush() error {
+	if l.sampler != nil {
+		l.logSummaries(l.sampler.drain(time.Now()))
+	}
+	if l.sinks == nil {
+		return nil
+	}
+	return l.sinks.flush()
+}
+
+func (l *SLog) Close() error {
+	if l.sampler != nil {
+		l.sampler.stop()
+		l.logSummaries(l.sampler.drain(time.Now()))
+	}
+	if l.sinks == nil {
+		return nil
+	}
+	return l.sinks.close()
+}
+
 func (f fields) getFieldsFromContext(ctx context.Context) {
 	dataMap := ctx.Value(ContextDataMapKey)
 
@@ -192,6 +324,8 @@ func getDefaultData(ctx context.Context, logLevel log.Level) fields {
 		data.getCallStackTrace()
 	}
 	data.getFieldsFromContext(ctx)
+	data.getFieldsFromBag(ctx)
+	data.getFieldsFromSpan(ctx)
 	return data
 }
 
@@ -71,6 +71,7 @@ type CommonFields struct {
 	Country   string            `json:"country"`
 	EventID   string            `json:"event_id"`
 	DataMap   map[string]string `json:"data_map"` // for additional data/custom field
+	Fields    []Field           `json:"-"`        // for additional typed field, see WithFields
 }
 
 func (c *CommonFields) ToDataMap() map[string]string {
@@ -100,6 +101,11 @@ func (l *SLog) LogRequestResponse(ctx context.Context, data *RequestResponse, ar
 	l.InfoMap(ctx, data.ToDataMap(), args...)
 }
 
+// SetContextData merges data over the context data already in ctx
 func (l *SLog) SetContextData(ctx context.Context, data *CommonFields) (cctx context.Context) {
-	return context.WithValue(ctx, ContextDataMapKey, data.ToDataMap())
+	cctx = WithContextData(ctx, data.ToDataMap())
+	if len(data.Fields) > 0 {
+		cctx = WithFields(cctx, data.Fields...)
+	}
+	return cctx
 }
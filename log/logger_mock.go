@@ -9,6 +9,6 @@ import logrusTest "github.com/sirupsen/logrus/hooks/test"
  * 		the right structure and contents or not.
  */
 func NewSLoggerWithTestHook(service string) (SLogger, *logrusTest.Hook) {
-	entry, logger := getEntryAndLogger(service)
-	return &SLog{entry}, logrusTest.NewLocal(logger)
+	entry, logger, _ := getEntryAndLogger(service, &config{})
+	return &SLog{entry: entry, overridden: newOverriddenEntry(entry)}, logrusTest.NewLocal(logger)
 }
@@ -0,0 +1,340 @@
+// Package logtest provides an in-memory log.SLogger to assert on what was logged, instead of mocking every call.
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is one captured log entry
+type Entry struct {
+	Level   logrus.Level
+	Message string
+	// Fields holds the data map, typed context fields, trace fields and error fields of the entry
+	Fields map[string]interface{}
+	// ContextData holds the context data of the entry, e.g. context_id and country
+	ContextData map[string]string
+}
+
+// Field returns value of key from Fields, or from ContextData when key is not a field
+func (e Entry) Field(key string) (interface{}, bool) {
+	if value, ok := e.Fields[key]; ok {
+		return value, true
+	}
+	value, ok := e.ContextData[key]
+	return value, ok
+}
+
+// Logger captures entries in memory. Levels follow SetLevel and log.WithForcedDebug,
+// caller levels are only stored. Fatal entries are captured without exiting.
+type Logger struct {
+	// base builds contexts the same way log.SLogger does
+	base log.SLogger
+
+	mu      sync.Mutex
+	level   logrus.Level
+	callers map[string]logrus.Level
+	entries []Entry
+}
+
+var (
+	_ log.SLogger         = (*Logger)(nil)
+	_ log.ErrorLogger     = (*Logger)(nil)
+	_ log.Flusher         = (*Logger)(nil)
+	_ log.LevelController = (*Logger)(nil)
+)
+
+// New returns a logger capturing entries at info level and above
+func New() *Logger {
+	base := log.NewSLogger("logtest")
+	base.GetEntry().Logger.SetOutput(ioutil.Discard)
+
+	return &Logger{
+		base:    base,
+		level:   logrus.InfoLevel,
+		callers: make(map[string]logrus.Level),
+	}
+}
+
+// Entries returns copy of the captured entries in logging order
+func (l *Logger) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]Entry, len(l.entries))
+	copy(entries, l.entries)
+	return entries
+}
+
+// LastEntry returns the last captured entry, false when nothing was logged
+func (l *Logger) LastEntry() (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 {
+		return Entry{}, false
+	}
+	return l.entries[len(l.entries)-1], true
+}
+
+// Reset removes the captured entries
+func (l *Logger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+}
+
+// Find returns the entries of level whose message contains msgContains and which have every field of fields.
+// Fields are looked up in Entry.Fields, then in Entry.ContextData.
+func (l *Logger) Find(level logrus.Level, msgContains string, fields map[string]interface{}) []Entry {
+	var found []Entry
+	for _, entry := range l.Entries() {
+		if entry.Level == level && strings.Contains(entry.Message, msgContains) && entry.hasFields(fields) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// AssertLogged fails t unless an entry matches level, msgContains and fields, see Find
+func (l *Logger) AssertLogged(t testing.TB, level logrus.Level, msgContains string, fields map[string]interface{}) bool {
+	t.Helper()
+
+	if len(l.Find(level, msgContains, fields)) > 0 {
+		return true
+	}
+	t.Errorf("no %s entry containing %q with fields %v, logged entries:\n%s", level, msgContains, fields, l.dump())
+	return false
+}
+
+// AssertNotLogged fails t when an entry matches level, msgContains and fields, see Find
+func (l *Logger) AssertNotLogged(t testing.TB, level logrus.Level, msgContains string, fields map[string]interface{}) bool {
+	t.Helper()
+
+	found := l.Find(level, msgContains, fields)
+	if len(found) == 0 {
+		return true
+	}
+	t.Errorf("unexpected %s entry containing %q with fields %v: %+v", level, msgContains, fields, found[0])
+	return false
+}
+
+func (e Entry) hasFields(fields map[string]interface{}) bool {
+	for key, expected := range fields {
+		actual, ok := e.Field(key)
+		if !ok || !equal(expected, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares numbers by value, so Int(1) matches an expected int64(1) or float64(1)
+func equal(expected, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	if expected == nil || actual == nil {
+		return false
+	}
+
+	e, a := reflect.ValueOf(expected), reflect.ValueOf(actual)
+	if isNumber(e.Kind()) && isNumber(a.Kind()) {
+		return fmt.Sprint(toFloat(e)) == fmt.Sprint(toFloat(a))
+	}
+	return false
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		return float64(v.Int())
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func (l *Logger) dump() string {
+	var b strings.Builder
+	for _, entry := range l.Entries() {
+		fmt.Fprintf(&b, "\t%s %q fields=%v context=%v\n", entry.Level, entry.Message, entry.Fields, entry.ContextData)
+	}
+	return b.String()
+}
+
+func (l *Logger) enabled(ctx context.Context, level logrus.Level) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return level <= l.level || (level <= logrus.DebugLevel && log.IsForcedDebug(ctx))
+}
+
+func (l *Logger) capture(ctx context.Context, level logrus.Level, message string, dataMap map[string]interface{}) {
+	if !l.enabled(ctx, level) {
+		return
+	}
+
+	entry := Entry{
+		Level:       level,
+		Message:     message,
+		Fields:      make(map[string]interface{}),
+		ContextData: make(map[string]string),
+	}
+	if ctx != nil {
+		if data, ok := ctx.Value(log.ContextDataMapKey).(map[string]string); ok {
+			for key, value := range data {
+				entry.ContextData[key] = value
+			}
+		}
+		for key, value := range log.ContextFields(ctx) {
+			entry.Fields[key] = value
+		}
+		for key, value := range log.TraceFields(ctx) {
+			entry.Fields[key] = value
+		}
+	}
+	for key, value := range dataMap {
+		entry.Fields[key] = value
+	}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func (l *Logger) BuildContextDataAndSetValue(country string, contextID string) (cctx context.Context) {
+	return l.base.BuildContextDataAndSetValue(country, contextID)
+}
+
+func (l *Logger) SetContextDataAndSetValue(r *http.Request, data map[string]string, country string, contextId string) *http.Request {
+	return l.base.SetContextDataAndSetValue(r, data, country, contextId)
+}
+
+func (l *Logger) SetContextData(ctx context.Context, data *log.CommonFields) (cctx context.Context) {
+	return l.base.SetContextData(ctx, data)
+}
+
+// GetEntry returns entry of a discarding logger, entries logged through it are not captured
+func (l *Logger) GetEntry() *logrus.Entry {
+	return l.base.GetEntry()
+}
+
+func (l *Logger) Infof(ctx context.Context, message string, args ...interface{}) {
+	l.capture(ctx, logrus.InfoLevel, fmt.Sprintf(message, args...), nil)
+}
+
+func (l *Logger) Errorf(ctx context.Context, message string, args ...interface{}) {
+	l.capture(ctx, logrus.ErrorLevel, fmt.Sprintf(message, args...), nil)
+}
+
+func (l *Logger) Warnf(ctx context.Context, message string, args ...interface{}) {
+	l.capture(ctx, logrus.WarnLevel, fmt.Sprintf(message, args...), nil)
+}
+
+func (l *Logger) Debugf(ctx context.Context, message string, args ...interface{}) {
+	l.capture(ctx, logrus.DebugLevel, fmt.Sprintf(message, args...), nil)
+}
+
+func (l *Logger) Fatalf(ctx context.Context, message string, args ...interface{}) {
+	l.capture(ctx, logrus.FatalLevel, fmt.Sprintf(message, args...), nil)
+}
+
+func (l *Logger) Info(ctx context.Context, args ...interface{}) {
+	l.capture(ctx, logrus.InfoLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Error(ctx context.Context, args ...interface{}) {
+	l.capture(ctx, logrus.ErrorLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Warn(ctx context.Context, args ...interface{}) {
+	l.capture(ctx, logrus.WarnLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Debug(ctx context.Context, args ...interface{}) {
+	l.capture(ctx, logrus.DebugLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) Fatal(ctx context.Context, args ...interface{}) {
+	l.capture(ctx, logrus.FatalLevel, fmt.Sprint(args...), nil)
+}
+
+func (l *Logger) InfoMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{}) {
+	l.capture(ctx, logrus.InfoLevel, fmt.Sprint(args...), dataMap)
+}
+
+func (l *Logger) ErrorMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{}) {
+	l.capture(ctx, logrus.ErrorLevel, fmt.Sprint(args...), dataMap)
+}
+
+func (l *Logger) ErrorErr(ctx context.Context, err error, msg string) {
+	var dataMap map[string]interface{}
+	if err != nil {
+		dataMap = map[string]interface{}{
+			log.FieldError:      err.Error(),
+			log.FieldErrorChain: log.ErrorChain(err),
+		}
+	}
+	l.capture(ctx, logrus.ErrorLevel, msg, dataMap)
+}
+
+func (l *Logger) LogRequestResponse(ctx context.Context, data *log.RequestResponse, args ...interface{}) {
+	l.InfoMap(ctx, data.ToDataMap(), args...)
+}
+
+func (l *Logger) SetLevel(level logrus.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+func (l *Logger) GetLevel() logrus.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+func (l *Logger) SetCallerLevel(caller string, level logrus.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.callers[caller] = level
+}
+
+func (l *Logger) UnsetCallerLevel(caller string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.callers, caller)
+}
+
+func (l *Logger) CallerLevels() map[string]logrus.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	levels := make(map[string]logrus.Level, len(l.callers))
+	for caller, level := range l.callers {
+		levels[caller] = level
+	}
+	return levels
+}
+
+func (l *Logger) Flush() error {
+	return nil
+}
+
+func (l *Logger) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package logtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/c2fo/testify/assert"
+	"github.com/muhammad-fakhri/go-libs/log"
+	"github.com/sirupsen/logrus"
+)
+
+// recorder collects failures instead of failing the test
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestLoggerCapturesEntries(t *testing.T) {
+	logger := New()
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+	ctx = log.WithFields(ctx, log.Int("order_id", 5))
+
+	logger.Infof(ctx, "order %d paid", 5)
+	logger.Debug(ctx, "dropped")
+	logger.ErrorMap(ctx, map[string]interface{}{"status": 500}, "save failed")
+	logger.ErrorErr(ctx, fmt.Errorf("save: %w", errors.New("timeout")), "checkout failed")
+
+	entries := logger.Entries()
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, logrus.InfoLevel, entries[0].Level)
+	assert.Equal(t, "order 5 paid", entries[0].Message)
+	assert.Equal(t, "ctx-1", entries[0].ContextData[log.ContextIdKey])
+	assert.Equal(t, 5, entries[0].Fields["order_id"])
+
+	logger.AssertLogged(t, logrus.InfoLevel, "paid", map[string]interface{}{"order_id": int64(5), log.ContextCountryKey: "ID"})
+	logger.AssertLogged(t, logrus.ErrorLevel, "save failed", map[string]interface{}{"status": 500.0})
+	logger.AssertLogged(t, logrus.ErrorLevel, "checkout", map[string]interface{}{log.FieldError: "save: timeout"})
+	logger.AssertNotLogged(t, logrus.DebugLevel, "", nil)
+
+	last, ok := logger.LastEntry()
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(last.Fields[log.FieldErrorChain].([]log.ErrorCause)))
+}
+
+func TestLoggerLevels(t *testing.T) {
+	logger := New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	logger.Warn(context.Background(), "dropped")
+	logger.Debug(log.WithForcedDebug(context.Background()), "forced")
+	assert.Equal(t, 1, len(logger.Entries()))
+	assert.Equal(t, "forced", logger.Entries()[0].Message)
+
+	logger.Reset()
+	_, ok := logger.LastEntry()
+	assert.False(t, ok)
+}
+
+func TestAssertLoggedFailure(t *testing.T) {
+	logger := New()
+	logger.Info(context.Background(), "hello")
+
+	r := &recorder{TB: t}
+	assert.False(t, logger.AssertLogged(r, logrus.InfoLevel, "hello", map[string]interface{}{"missing": 1}))
+	assert.False(t, logger.AssertNotLogged(r, logrus.InfoLevel, "hello", nil))
+	assert.Equal(t, 2, len(r.failures))
+	assert.Contains(t, r.failures[0], `info "hello"`)
+}
@@ -9,8 +9,8 @@ import (
 	http "net/http"
 	reflect "reflect"
 
-	log "github.com/muhammad-fakhri/go-libs/log"
 	gomock "github.com/golang/mock/gomock"
+	log "github.com/muhammad-fakhri/go-libs/log"
 	logrus "github.com/sirupsen/logrus"
 )
 
@@ -325,3 +325,176 @@ func (mr *MockSLoggerMockRecorder) Warnf(ctx, message interface{}, args ...inter
 	varargs := append([]interface{}{ctx, message}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockSLogger)(nil).Warnf), varargs...)
 }
+
+// MockErrorLogger is a mock of ErrorLogger interface.
+type MockErrorLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockErrorLoggerMockRecorder
+}
+
+// MockErrorLoggerMockRecorder is the mock recorder for MockErrorLogger.
+type MockErrorLoggerMockRecorder struct {
+	mock *MockErrorLogger
+}
+
+// NewMockErrorLogger creates a new mock instance.
+func NewMockErrorLogger(ctrl *gomock.Controller) *MockErrorLogger {
+	mock := &MockErrorLogger{ctrl: ctrl}
+	mock.recorder = &MockErrorLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockErrorLogger) EXPECT() *MockErrorLoggerMockRecorder {
+	return m.recorder
+}
+
+// ErrorErr mocks base method.
+func (m *MockErrorLogger) ErrorErr(ctx context.Context, err error, msg string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ErrorErr", ctx, err, msg)
+}
+
+// ErrorErr indicates an expected call of ErrorErr.
+func (mr *MockErrorLoggerMockRecorder) ErrorErr(ctx, err, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ErrorErr", reflect.TypeOf((*MockErrorLogger)(nil).ErrorErr), ctx, err, msg)
+}
+
+// MockLevelController is a mock of LevelController interface.
+type MockLevelController struct {
+	ctrl     *gomock.Controller
+	recorder *MockLevelControllerMockRecorder
+}
+
+// MockLevelControllerMockRecorder is the mock recorder for MockLevelController.
+type MockLevelControllerMockRecorder struct {
+	mock *MockLevelController
+}
+
+// NewMockLevelController creates a new mock instance.
+func NewMockLevelController(ctrl *gomock.Controller) *MockLevelController {
+	mock := &MockLevelController{ctrl: ctrl}
+	mock.recorder = &MockLevelControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLevelController) EXPECT() *MockLevelControllerMockRecorder {
+	return m.recorder
+}
+
+// CallerLevels mocks base method.
+func (m *MockLevelController) CallerLevels() map[string]logrus.Level {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CallerLevels")
+	ret0, _ := ret[0].(map[string]logrus.Level)
+	return ret0
+}
+
+// CallerLevels indicates an expected call of CallerLevels.
+func (mr *MockLevelControllerMockRecorder) CallerLevels() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallerLevels", reflect.TypeOf((*MockLevelController)(nil).CallerLevels))
+}
+
+// GetLevel mocks base method.
+func (m *MockLevelController) GetLevel() logrus.Level {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLevel")
+	ret0, _ := ret[0].(logrus.Level)
+	return ret0
+}
+
+// GetLevel indicates an expected call of GetLevel.
+func (mr *MockLevelControllerMockRecorder) GetLevel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLevel", reflect.TypeOf((*MockLevelController)(nil).GetLevel))
+}
+
+// SetCallerLevel mocks base method.
+func (m *MockLevelController) SetCallerLevel(caller string, level logrus.Level) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetCallerLevel", caller, level)
+}
+
+// SetCallerLevel indicates an expected call of SetCallerLevel.
+func (mr *MockLevelControllerMockRecorder) SetCallerLevel(caller, level interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCallerLevel", reflect.TypeOf((*MockLevelController)(nil).SetCallerLevel), caller, level)
+}
+
+// SetLevel mocks base method.
+func (m *MockLevelController) SetLevel(level logrus.Level) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLevel", level)
+}
+
+// SetLevel indicates an expected call of SetLevel.
+func (mr *MockLevelControllerMockRecorder) SetLevel(level interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLevel", reflect.TypeOf((*MockLevelController)(nil).SetLevel), level)
+}
+
+// UnsetCallerLevel mocks base method.
+func (m *MockLevelController) UnsetCallerLevel(caller string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UnsetCallerLevel", caller)
+}
+
+// UnsetCallerLevel indicates an expected call of UnsetCallerLevel.
+func (mr *MockLevelControllerMockRecorder) UnsetCallerLevel(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsetCallerLevel", reflect.TypeOf((*MockLevelController)(nil).UnsetCallerLevel), caller)
+}
+
+// MockFlusher is a mock of Flusher interface.
+type MockFlusher struct {
+	ctrl     *gomock.Controller
+	recorder *MockFlusherMockRecorder
+}
+
+// MockFlusherMockRecorder is the mock recorder for MockFlusher.
+type MockFlusherMockRecorder struct {
+	mock *MockFlusher
+}
+
+// NewMockFlusher creates a new mock instance.
+func NewMockFlusher(ctrl *gomock.Controller) *MockFlusher {
+	mock := &MockFlusher{ctrl: ctrl}
+	mock.recorder = &MockFlusherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFlusher) EXPECT() *MockFlusherMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockFlusher) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockFlusherMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockFlusher)(nil).Close))
+}
+
+// Flush mocks base method.
+func (m *MockFlusher) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockFlusherMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockFlusher)(nil).Flush))
+}
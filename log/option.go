@@ -0,0 +1,37 @@
+package log
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Option initializes configs
+type Option func(c *config)
+
+type config struct {
+	sinks    []*levelSink
+	async    *AsyncConfig
+	sampling *SamplingConfig
+}
+
+// WithSink adds sink that receives entries with level lower or equal to level, e.g. log.WarnLevel receives warn, error and fatal.
+// Entries are written to stderr when no sink is added.
+func WithSink(sink Sink, level log.Level) Option {
+	return func(c *config) {
+		c.sinks = append(c.sinks, &levelSink{Sink: sink, level: level})
+	}
+}
+
+// WithAsync buffers entries of every sink in a ring buffer, written by a background goroutine
+func WithAsync(asyncConfig AsyncConfig) Option {
+	return func(c *config) {
+		c.async = &asyncConfig
+	}
+}
+
+// WithSampling drops repeated entries of the same message template following the per level policies.
+// With Dedup the summaries are logged in the background until Close.
+func WithSampling(samplingConfig SamplingConfig) Option {
+	return func(c *config) {
+		c.sampling = &samplingConfig
+	}
+}
@@ -0,0 +1,562 @@
+package log
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// MaskStrategy decides how a sensitive value is replaced
+type MaskStrategy int
+
+const (
+	// MaskFull replaces the whole value with "[REDACTED]"
+	MaskFull = MaskStrategy(iota)
+	// MaskPartial keeps the last 4 characters and masks the rest with '*'
+	MaskPartial
+	// MaskHash replaces the value with a truncated sha256, so equal values can still be correlated
+	MaskHash
+)
+
+const (
+	redactedValue    = "[REDACTED]"
+	partialKeepChars = 4
+	hashPrefix       = "sha256:"
+	hashLength       = 16
+)
+
+// Mask returns value masked with the strategy
+func (s MaskStrategy) Mask(value string) string {
+	switch s {
+	case MaskPartial:
+		runes := []rune(value)
+		if len(runes) <= partialKeepChars {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-partialKeepChars) + string(runes[len(runes)-partialKeepChars:])
+	case MaskHash:
+		sum := sha256.Sum256([]byte(value))
+		return hashPrefix + hex.EncodeToString(sum[:])[:hashLength]
+	default:
+		return redactedValue
+	}
+}
+
+// maskValue masks any decoded JSON value, non string values are masked by their JSON text
+func (s MaskStrategy) maskValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return s.Mask(v)
+	case json.Number:
+		return s.Mask(v.String())
+	default:
+		if s == MaskFull {
+			return redactedValue
+		}
+		text, err := json.Marshal(v)
+		if err != nil {
+			return redactedValue
+		}
+		return s.Mask(string(text))
+	}
+}
+
+// Detector finds sensitive data inside free text
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate filters out false positives, nil accepts every match
+	Validate func(match string) bool
+}
+
+var (
+	EmailDetector = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+	// PhoneDetector matches 10 to 15 digit numbers starting with a country code, e.g. +62 812-3456-7890,
+	// or plain numbers starting with a trunk prefix, e.g. 081234567890. Dates, times and ids like 0012345678 don't match.
+	PhoneDetector = Detector{
+		Name:     "phone",
+		Pattern:  regexp.MustCompile(`\+\d{1,3}[ \-]?\(?\d{1,4}\)?(?:[ \-]?\d{2,4}){2,4}\b|\b0[1-9]\d{8,13}\b`),
+		Validate: phoneValid,
+	}
+	// CardDetector matches 13 to 19 digit card numbers passing the Luhn check
+	CardDetector = Detector{
+		Name:     "card",
+		Pattern:  regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		Validate: luhnValid,
+	}
+)
+
+type fieldRule struct {
+	segments []string
+	// anchored rules match the path from the root, otherwise only the key name is compared
+	anchored bool
+	strategy MaskStrategy
+}
+
+// patternRule matches a header or query parameter name by glob pattern
+type patternRule struct {
+	pattern  string
+	strategy MaskStrategy
+}
+
+type detectorRule struct {
+	Detector
+	strategy MaskStrategy
+}
+
+// Redactor masks sensitive headers, JSON body fields and free text PII before they are logged.
+// A nil Redactor leaves everything untouched.
+type Redactor struct {
+	fields      []fieldRule
+	headers     []patternRule
+	queryParams []patternRule
+	detectors   []detectorRule
+}
+
+// RedactOption configures Redactor
+type RedactOption func(r *Redactor)
+
+// NewRedactor returns redactor with the given rules, without options nothing is masked
+func NewRedactor(options ...RedactOption) *Redactor {
+	r := &Redactor{}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// DefaultRedactor masks credential headers and fields and partially masks email, phone and card numbers
+func DefaultRedactor() *Redactor {
+	return NewRedactor(
+		RedactHeaders(MaskFull, "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "*token*", "*secret*"),
+		RedactFields(MaskFull, "password", "passwd", "pin", "secret", "token", "access_token", "refresh_token", "id_token",
+			"api_key", "apikey", "client_secret", "private_key", "cvv", "cvc"),
+		RedactPII(MaskPartial),
+	)
+}
+
+// RedactFields masks JSON body fields by key name at any depth, e.g. "password",
+// or by JSON path from the root, e.g. "$.user.email", "user.cards[*].number" or "data.*.token".
+// Arrays are traversed transparently and "*" matches any single key. Keys are case insensitive.
+func RedactFields(strategy MaskStrategy, keysOrPaths ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, p := range keysOrPaths {
+			if rule, ok := parseFieldRule(p, strategy); ok {
+				r.fields = append(r.fields, rule)
+			}
+		}
+	}
+}
+
+// RedactHeaders masks header values whose name matches a case insensitive glob pattern, e.g. "X-*-Token"
+func RedactHeaders(strategy MaskStrategy, patterns ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, p := range patterns {
+			r.headers = append(r.headers, patternRule{pattern: strings.ToLower(p), strategy: strategy})
+		}
+	}
+}
+
+// RedactQueryParams masks query parameter values of logged urls whose name matches a case insensitive glob pattern,
+// e.g. "sig*". Parameters named like a key of RedactFields are masked too.
+func RedactQueryParams(strategy MaskStrategy, patterns ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, p := range patterns {
+			r.queryParams = append(r.queryParams, patternRule{pattern: strings.ToLower(p), strategy: strategy})
+		}
+	}
+}
+
+// RedactDetector masks every match of detector found in text bodies and JSON string values
+func RedactDetector(detector Detector, strategy MaskStrategy) RedactOption {
+	return func(r *Redactor) {
+		r.detectors = append(r.detectors, detectorRule{Detector: detector, strategy: strategy})
+	}
+}
+
+// RedactPII masks card numbers, emails and phone numbers
+func RedactPII(strategy MaskStrategy) RedactOption {
+	return func(r *Redactor) {
+		// card goes first, phone pattern also matches part of a card number
+		for _, d := range []Detector{CardDetector, EmailDetector, PhoneDetector} {
+			RedactDetector(d, strategy)(r)
+		}
+	}
+}
+
+func parseFieldRule(p string, strategy MaskStrategy) (fieldRule, bool) {
+	p = strings.TrimSpace(p)
+	anchored := strings.HasPrefix(p, "$")
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	p = strings.NewReplacer("[*]", "", "[]", "").Replace(p)
+	if p == "" {
+		return fieldRule{}, false
+	}
+
+	segments := strings.Split(strings.ToLower(p), ".")
+	return fieldRule{
+		segments: segments,
+		anchored: anchored || len(segments) > 1,
+		strategy: strategy,
+	}, true
+}
+
+func (f fieldRule) match(keys []string) bool {
+	if !f.anchored {
+		return strings.EqualFold(keys[len(keys)-1], f.segments[0])
+	}
+	if len(keys) != len(f.segments) {
+		return false
+	}
+	for i, segment := range f.segments {
+		if segment != "*" && !strings.EqualFold(keys[i], segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// RedactRequestResponse masks the url query, headers and bodies of data in place
+func (r *Redactor) RedactRequestResponse(data *RequestResponse) {
+	if r == nil || data == nil {
+		return
+	}
+
+	data.URLPath = r.RedactURL(data.URLPath)
+	data.RequestHeader = r.RedactHeader(data.RequestHeader)
+	data.ResponseHeader = r.RedactHeader(data.ResponseHeader)
+	data.RequestBody = r.RedactValue(data.RequestBody)
+	data.ResponseBody = r.RedactValue(data.ResponseBody)
+}
+
+// RedactHeader returns copy of header with sensitive values masked, header is returned as is when nothing matches
+func (r *Redactor) RedactHeader(header http.Header) http.Header {
+	if r == nil || len(r.headers) == 0 || header == nil {
+		return header
+	}
+
+	var redacted http.Header
+	for name, values := range header {
+		strategy, ok := r.headerStrategy(name)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = header.Clone()
+		}
+		masked := make([]string, len(values))
+		for i, v := range values {
+			masked[i] = strategy.Mask(v)
+		}
+		redacted[name] = masked
+	}
+
+	if redacted == nil {
+		return header
+	}
+	return redacted
+}
+
+func (r *Redactor) headerStrategy(name string) (MaskStrategy, bool) {
+	return matchPattern(r.headers, name)
+}
+
+func matchPattern(rules []patternRule, name string) (MaskStrategy, bool) {
+	name = strings.ToLower(name)
+	for _, rule := range rules {
+		if ok, _ := path.Match(rule.pattern, name); ok {
+			return rule.strategy, true
+		}
+	}
+	return 0, false
+}
+
+// RedactURL masks the query parameters of rawURL by name, other values only by detectors.
+// The rest of rawURL is kept, e.g. the method of "GET /users?token=abc", and rawURL is returned as is when nothing matches.
+func (r *Redactor) RedactURL(rawURL string) string {
+	i := strings.IndexByte(rawURL, '?')
+	if r == nil || i < 0 {
+		return rawURL
+	}
+
+	params := strings.Split(rawURL[i+1:], "&")
+	changed := false
+	for j, param := range params {
+		name, value := param, ""
+		if k := strings.IndexByte(param, '='); k >= 0 {
+			name, value = param[:k], param[k+1:]
+		}
+		key, err := url.QueryUnescape(name)
+		if err != nil {
+			key = name
+		}
+		unescaped, err := url.QueryUnescape(value)
+		if err != nil {
+			unescaped = value
+		}
+
+		strategy, ok := matchPattern(r.queryParams, key)
+		if !ok {
+			strategy, ok = r.fieldStrategy([]string{key})
+		}
+		if ok {
+			params[j], changed = name+"="+strategy.Mask(unescaped), true
+		} else if redacted, found := r.redactText(unescaped); found {
+			params[j], changed = name+"="+redacted, true
+		}
+	}
+
+	if !changed {
+		return rawURL
+	}
+	return rawURL[:i+1] + strings.Join(params, "&")
+}
+
+// RedactBody masks a JSON body by field rules and detectors, other bodies only by detectors.
+// A body starting like JSON which can't be decoded, e.g. truncated for logging, has the values of keys named
+// like a field rule masked in its text, see redactJSONText. Body is returned as is when nothing matches.
+func (r *Redactor) RedactBody(body string) string {
+	if r == nil {
+		return body
+	}
+
+	if decoded, ok := decodeJSON([]byte(body)); ok {
+		if redacted, changed := r.redactJSON(decoded, nil); changed {
+			if text, err := encodeJSON(redacted); err == nil {
+				return text
+			}
+			return redactedValue
+		}
+		return body
+	}
+
+	if trimmed := strings.TrimSpace(body); len(r.fields) > 0 && trimmed != "" && (trimmed[0] == '{' || trimmed[0] == '[') {
+		body = r.redactJSONText(body)
+	}
+	redacted, _ := r.redactText(body)
+	return redacted
+}
+
+// jsonKeyPattern matches a quoted object key with its colon
+var jsonKeyPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*`)
+
+// redactJSONText masks the values of keys named like a field rule in JSON text which can't be decoded.
+// Without the document structure a path rule is matched by its last key only. A masked object or array
+// hides the rest of text, since its end can't be told.
+func (r *Redactor) redactJSONText(text string) string {
+	var b strings.Builder
+	for {
+		loc := jsonKeyPattern.FindStringSubmatchIndex(text)
+		if loc == nil {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:loc[1]])
+		key := text[loc[2]:loc[3]]
+		text = text[loc[1]:]
+
+		strategy, ok := r.keyStrategy(key)
+		if !ok {
+			continue
+		}
+		var masked string
+		masked, text = maskJSONText(text, strategy)
+		b.WriteString(masked)
+	}
+}
+
+// keyStrategy returns the strategy of the first field rule whose last key is key
+func (r *Redactor) keyStrategy(key string) (MaskStrategy, bool) {
+	for _, rule := range r.fields {
+		last := rule.segments[len(rule.segments)-1]
+		if last == "*" || strings.EqualFold(key, last) {
+			return rule.strategy, true
+		}
+	}
+	return 0, false
+}
+
+// maskJSONText masks the JSON value at the start of text, which may be cut anywhere, and returns the text after it
+func maskJSONText(text string, strategy MaskStrategy) (masked, rest string) {
+	switch {
+	case text == "":
+		return "", ""
+	case text[0] == '{' || text[0] == '[':
+		return `"` + redactedValue + `"`, ""
+	case text[0] == '"':
+		for i := 1; i < len(text); i++ {
+			switch text[i] {
+			case '\\':
+				i++
+			case '"':
+				return `"` + strategy.Mask(text[1:i]) + `"`, text[i+1:]
+			}
+		}
+		return `"` + strategy.Mask(text[1:]), ""
+	default:
+		end := strings.IndexAny(text, ",}] \t\r\n")
+		if end < 0 {
+			end = len(text)
+		}
+		return `"` + strategy.Mask(text[:end]) + `"`, text[end:]
+	}
+}
+
+// RedactValue masks a logged value, strings and bytes are handled as body,
+// other values are redacted on their JSON representation
+func (r *Redactor) RedactValue(value interface{}) interface{} {
+	if r == nil || value == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.RedactBody(v)
+	case []byte:
+		return r.RedactBody(string(v))
+	case json.RawMessage:
+		return r.RedactBody(string(v))
+	}
+
+	if len(r.fields) == 0 && len(r.detectors) == 0 {
+		return value
+	}
+
+	text, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	decoded, ok := decodeJSON(text)
+	if !ok {
+		return value
+	}
+	if redacted, changed := r.redactJSON(decoded, nil); changed {
+		return redacted
+	}
+	return value
+}
+
+func (r *Redactor) redactJSON(value interface{}, keys []string) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		changed := false
+		for key, child := range v {
+			childKeys := append(keys, key)
+			if strategy, ok := r.fieldStrategy(childKeys); ok {
+				v[key] = strategy.maskValue(child)
+				changed = true
+				continue
+			}
+			if redacted, ok := r.redactJSON(child, childKeys); ok {
+				v[key] = redacted
+				changed = true
+			}
+		}
+		return v, changed
+	case []interface{}:
+		changed := false
+		for i, child := range v {
+			if redacted, ok := r.redactJSON(child, keys); ok {
+				v[i] = redacted
+				changed = true
+			}
+		}
+		return v, changed
+	case string:
+		return r.redactText(v)
+	default:
+		return value, false
+	}
+}
+
+func (r *Redactor) fieldStrategy(keys []string) (MaskStrategy, bool) {
+	for _, rule := range r.fields {
+		if rule.match(keys) {
+			return rule.strategy, true
+		}
+	}
+	return 0, false
+}
+
+func (r *Redactor) redactText(text string) (string, bool) {
+	changed := false
+	for _, rule := range r.detectors {
+		rule := rule
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.Validate != nil && !rule.Validate(match) {
+				return match
+			}
+			changed = true
+			return rule.strategy.Mask(match)
+		})
+	}
+	return text, changed
+}
+
+func decodeJSON(data []byte) (interface{}, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil || decoder.More() {
+		return nil, false
+	}
+	return decoded, true
+}
+
+func encodeJSON(value interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", fmt.Errorf("encode redacted body: %v", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func phoneValid(number string) bool {
+	digits := 0
+	for i := 0; i < len(number); i++ {
+		if number[i] >= '0' && number[i] <= '9' {
+			digits++
+		}
+	}
+	return digits >= 10 && digits <= 15
+}
+
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,137 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/c2fo/testify/assert"
+)
+
+func TestMaskStrategy(t *testing.T) {
+	assert.Equal(t, "[REDACTED]", MaskFull.Mask("secret"))
+	assert.Equal(t, "********1234", MaskPartial.Mask("081200001234"))
+	assert.Equal(t, "***", MaskPartial.Mask("abc"))
+
+	hashed := MaskHash.Mask("secret")
+	assert.True(t, strings.HasPrefix(hashed, "sha256:"))
+	assert.Equal(t, hashed, MaskHash.Mask("secret"))
+	assert.NotEqual(t, hashed, MaskHash.Mask("other"))
+}
+
+func TestRedactBodyFields(t *testing.T) {
+	r := NewRedactor(
+		RedactFields(MaskFull, "password"),
+		RedactFields(MaskPartial, "$.user.phone", "cards[*].number"),
+		RedactFields(MaskHash, "data.*.token"),
+	)
+
+	body := `{"password":"p4ss","user":{"name":"a","phone":"081200001234","password":"x"},` +
+		`"cards":[{"number":"4111111111111111"},{"number":1234567890}],` +
+		`"data":{"a":{"token":"t1"},"b":{"token":"t1"}},"phone":"not anchored","amount":10.50}`
+
+	redacted := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(r.RedactBody(body)), &redacted))
+
+	assert.Equal(t, "[REDACTED]", redacted["password"])
+	user := redacted["user"].(map[string]interface{})
+	assert.Equal(t, "a", user["name"])
+	assert.Equal(t, "********1234", user["phone"])
+	assert.Equal(t, "[REDACTED]", user["password"])
+	cards := redacted["cards"].([]interface{})
+	assert.Equal(t, "************1111", cards[0].(map[string]interface{})["number"])
+	assert.Equal(t, "******7890", cards[1].(map[string]interface{})["number"])
+	data := redacted["data"].(map[string]interface{})
+	assert.True(t, strings.HasPrefix(data["a"].(map[string]interface{})["token"].(string), "sha256:"))
+	assert.Equal(t, "not anchored", redacted["phone"])
+	assert.Equal(t, 10.5, redacted["amount"])
+}
+
+func TestRedactBodyUnchanged(t *testing.T) {
+	r := DefaultRedactor()
+
+	body := `{ "name": "muhammad-fakhri", "amount": 100000000000000000001 }`
+	assert.Equal(t, body, r.RedactBody(body))
+	assert.Equal(t, "Hello World!", r.RedactBody("Hello World!"))
+}
+
+func TestRedactBodyTruncatedJSON(t *testing.T) {
+	r := NewRedactor(
+		RedactFields(MaskFull, "password", "$.user.pin"),
+		RedactFields(MaskPartial, "phone"),
+	)
+
+	body := `{"name":"a","password":"p\"4ss", "phone" : 81200001234,"user":{"pin":"123456"},"note":"pass`
+	assert.Equal(t, `{"name":"a","password":"[REDACTED]", "phone" : "*******1234","user":{"pin":"[REDACTED]"},"note":"pass`,
+		r.RedactBody(body))
+
+	assert.Equal(t, `{"id":1,"password":"[REDACTED]`, r.RedactBody(`{"id":1,"password":"p4ss...(truncated)`))
+	assert.Equal(t, `[{"id":1,"password":"[REDACTED]"`, r.RedactBody(`[{"id":1,"password":{"old":"a","new":"b"`))
+	assert.Equal(t, `{"id":1,"phone":"********1234`, r.RedactBody(`{"id":1,"phone":"081200001234`))
+}
+
+func TestRedactDetectors(t *testing.T) {
+	r := NewRedactor(RedactPII(MaskFull))
+
+	text := "mail john.doe@example.com, call +62 812-3456-7890 or 081234567890, pay 4111 1111 1111 1111, order 1234567890123"
+	assert.Equal(t, "mail [REDACTED], call [REDACTED] or [REDACTED], pay [REDACTED], order 1234567890123", r.RedactBody(text))
+
+	unchanged := "at 2023-01-05 10:20:30+07:00, order 0012345678, ref 1672912830000, code +62 12"
+	assert.Equal(t, unchanged, r.RedactBody(unchanged))
+
+	redacted := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(r.RedactBody(`{"note":"contact john@example.com"}`)), &redacted))
+	assert.Equal(t, "contact [REDACTED]", redacted["note"])
+}
+
+func TestRedactHeader(t *testing.T) {
+	r := NewRedactor(RedactHeaders(MaskFull, "authorization", "x-*-token"))
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc")
+	header.Set("X-Refresh-Token", "abc")
+	header.Set("X-Country", "ID")
+
+	redacted := r.RedactHeader(header)
+	assert.Equal(t, "[REDACTED]", redacted.Get("Authorization"))
+	assert.Equal(t, "[REDACTED]", redacted.Get("X-Refresh-Token"))
+	assert.Equal(t, "ID", redacted.Get("X-Country"))
+	// original header is left untouched
+	assert.Equal(t, "Bearer abc", header.Get("Authorization"))
+}
+
+func TestRedactRequestResponse(t *testing.T) {
+	type payload struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	data := &RequestResponse{
+		RequestHeader: http.Header{"Cookie": []string{"session=abc"}},
+		RequestBody:   &payload{Email: "john@example.com", Password: "p4ss"},
+		ResponseBody:  []byte(`{"access_token":"abc"}`),
+	}
+	DefaultRedactor().RedactRequestResponse(data)
+
+	assert.Equal(t, "[REDACTED]", data.RequestHeader.Get("Cookie"))
+	assert.Equal(t, map[string]interface{}{"email": "************.com", "password": "[REDACTED]"}, data.RequestBody)
+	assert.Equal(t, `{"access_token":"[REDACTED]"}`, data.ResponseBody)
+
+	var nilRedactor *Redactor
+	nilRedactor.RedactRequestResponse(data)
+}
+
+func TestRedactURL(t *testing.T) {
+	redactor := NewRedactor(RedactQueryParams(MaskFull, "sig*"), RedactFields(MaskFull, "token"), RedactPII(MaskPartial))
+
+	assert.Equal(t, "GET /users?token=[REDACTED]&page=2&signature=[REDACTED]",
+		redactor.RedactURL("GET /users?token=abc&page=2&signature=x%2By"))
+	assert.Equal(t, "/users?email=************.com", redactor.RedactURL("/users?email=john%40example.com"))
+	assert.Equal(t, "/users?page=2", redactor.RedactURL("/users?page=2"))
+	assert.Equal(t, "/users/42", redactor.RedactURL("/users/42"))
+
+	data := &RequestResponse{URLPath: "POST /login?access_token=abc"}
+	DefaultRedactor().RedactRequestResponse(data)
+	assert.Equal(t, "POST /login?access_token=[REDACTED]", data.URLPath)
+}
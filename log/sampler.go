@@ -0,0 +1,274 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSamplingTick = time.Second
+	// maxSampledTemplates bounds the counters kept in one tick, templates beyond it are not sampled
+	maxSampledTemplates = 10000
+
+	FieldRepeated = "repeated"
+)
+
+// SamplingPolicy logs the First entries of a message template in every tick, then every Thereafter-th entry.
+// Thereafter 0 drops every entry after First.
+type SamplingPolicy struct {
+	First      int
+	Thereafter int
+}
+
+type SamplingConfig struct {
+	// Tick is the sampling window, default is 1s
+	Tick time.Duration
+	// Levels maps level to its policy, levels without policy are never sampled. Fatal and panic are never sampled.
+	Levels map[log.Level]SamplingPolicy
+	// Dedup logs "<template> (repeated N times)" for entries dropped in a tick.
+	// Summaries are written within a tick after it ended, by the first entry of the next tick, Flush or Close.
+	Dedup bool
+}
+
+type sampleKey struct {
+	level    log.Level
+	template string
+}
+
+type sampleSummary struct {
+	sampleKey
+	dropped uint64
+}
+
+type sampleCounter struct {
+	count   uint64
+	dropped uint64
+}
+
+type sampler struct {
+	cfg SamplingConfig
+
+	mu       sync.Mutex
+	tickEnd  time.Time
+	counters map[sampleKey]*sampleCounter
+
+	// done stops the summaries of Dedup, see SLog.summarize
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newSampler(samplingConfig SamplingConfig) *sampler {
+	if samplingConfig.Tick <= 0 {
+		samplingConfig.Tick = defaultSamplingTick
+	}
+
+	return &sampler{
+		cfg:      samplingConfig,
+		counters: make(map[sampleKey]*sampleCounter),
+		done:     make(chan struct{}),
+	}
+}
+
+// check reports whether the entry is logged, together with summaries of the tick that just ended
+func (s *sampler) check(level log.Level, template string, now time.Time) (bool, []sampleSummary) {
+	policy, ok := s.cfg.Levels[level]
+	if !ok || level <= log.FatalLevel {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	summaries := s.rotateLocked(now)
+
+	key := sampleKey{level: level, template: template}
+	counter, ok := s.counters[key]
+	if !ok {
+		if len(s.counters) >= maxSampledTemplates {
+			s.mu.Unlock()
+			return true, summaries
+		}
+		counter = &sampleCounter{}
+		s.counters[key] = counter
+	}
+	counter.count++
+
+	sampled := counter.count <= uint64(policy.First) ||
+		(policy.Thereafter > 0 && (counter.count-uint64(policy.First))%uint64(policy.Thereafter) == 0)
+	if !sampled {
+		counter.dropped++
+	}
+	s.mu.Unlock()
+
+	return sampled, summaries
+}
+
+// rotateLocked resets counters once the tick ended and returns summaries of dropped entries
+func (s *sampler) rotateLocked(now time.Time) []sampleSummary {
+	if now.Before(s.tickEnd) {
+		return nil
+	}
+	s.tickEnd = now.Add(s.cfg.Tick)
+
+	if len(s.counters) == 0 {
+		return nil
+	}
+
+	var summaries []sampleSummary
+	if s.cfg.Dedup {
+		for key, counter := range s.counters {
+			if counter.dropped > 0 {
+				summaries = append(summaries, sampleSummary{sampleKey: key, dropped: counter.dropped})
+			}
+		}
+	}
+	s.counters = make(map[sampleKey]*sampleCounter)
+
+	return summaries
+}
+
+// expire returns summaries of the tick when it ended, without waiting for the next entry
+func (s *sampler) expire(now time.Time) []sampleSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rotateLocked(now)
+}
+
+func (s *sampler) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// drain returns summaries of the current tick and starts a new one
+func (s *sampler) drain(now time.Time) []sampleSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tickEnd = time.Time{}
+	return s.rotateLocked(now)
+}
+
+// sampled reports whether an enabled entry of level and template should be logged.
+// Entries of a context marked with MarkDebug, WithForcedDebug or withoutSampling are always logged.
+func (l *SLog) sampled(ctx context.Context, level log.Level, template string) bool {
+	if l.sampler == nil || isDebugMarked(ctx) || IsForcedDebug(ctx) || isUnsampled(ctx) {
+		return true
+	}
+
+	ok, summaries := l.sampler.check(level, template, time.Now())
+	l.logSummaries(summaries)
+	return ok
+}
+
+type unsampledKeyType struct{}
+
+var unsampledKey = unsampledKeyType{}
+
+// withoutSampling returns context whose entries are never sampled, e.g. recovered panics
+func withoutSampling(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, unsampledKey, true)
+}
+
+func isUnsampled(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	unsampled, _ := ctx.Value(unsampledKey).(bool)
+	return unsampled
+}
+
+// summarize logs the summaries of every tick once it ended, until the sampler is stopped by Close
+func (l *SLog) summarize() {
+	ticker := time.NewTicker(l.sampler.cfg.Tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			l.logSummaries(l.sampler.expire(now))
+		case <-l.sampler.done:
+			return
+		}
+	}
+}
+
+func (l *SLog) logSummaries(summaries []sampleSummary) {
+	for _, summary := range summaries {
+		l.entry.WithField(FieldRepeated, summary.dropped).
+			Log(summary.level, fmt.Sprintf("%s (repeated %d times)", summary.template, summary.dropped))
+	}
+}
+
+func argsTemplate(args []interface{}) string {
+	if len(args) == 1 {
+		if s, ok := args[0].(string); ok {
+			return s
+		}
+	}
+	return fmt.Sprint(args...)
+}
+
+// mapTemplate uses url path of request response entries logged without message
+func mapTemplate(dataMap map[string]interface{}, args []interface{}) string {
+	if len(args) == 0 {
+		if url, ok := dataMap[FieldURL].(string); ok {
+			return url
+		}
+	}
+	return argsTemplate(args)
+}
+
+type debugRegistry struct {
+	mu  sync.RWMutex
+	ids map[string]time.Time
+}
+
+var debugMarks = &debugRegistry{ids: make(map[string]time.Time)}
+
+// MarkDebug makes every entry of contextID bypass sampling for ttl, ttl <= 0 keeps it until UnmarkDebug
+func MarkDebug(contextID string, ttl time.Duration) {
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = time.Now().Add(ttl)
+	}
+
+	debugMarks.mu.Lock()
+	defer debugMarks.mu.Unlock()
+
+	now := time.Now()
+	for id, e := range debugMarks.ids {
+		if !e.IsZero() && now.After(e) {
+			delete(debugMarks.ids, id)
+		}
+	}
+	debugMarks.ids[contextID] = expiry
+}
+
+// UnmarkDebug removes the mark added by MarkDebug
+func UnmarkDebug(contextID string) {
+	debugMarks.mu.Lock()
+	defer debugMarks.mu.Unlock()
+	delete(debugMarks.ids, contextID)
+}
+
+func isDebugMarked(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	data, ok := ctx.Value(ContextDataMapKey).(map[string]string)
+	if !ok || data[ContextIdKey] == "" {
+		return false
+	}
+
+	debugMarks.mu.RLock()
+	defer debugMarks.mu.RUnlock()
+
+	expiry, ok := debugMarks.ids[data[ContextIdKey]]
+	return ok && (expiry.IsZero() || time.Now().Before(expiry))
+}
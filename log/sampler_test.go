@@ -0,0 +1,119 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/assert"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestSamplingPolicy(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewSLogger("svc", WithSink(sink, log.DebugLevel), WithSampling(SamplingConfig{
+		Tick:   time.Hour,
+		Levels: map[log.Level]SamplingPolicy{log.ErrorLevel: {First: 2, Thereafter: 3}},
+		Dedup:  true,
+	}))
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+
+	for i := 0; i < 10; i++ {
+		logger.Errorf(ctx, "failed to get user %d", i)
+		logger.Infof(ctx, "not sampled %d", i)
+	}
+	logger.Error(ctx, "other template")
+
+	// 2 first entries, then entry 5 and 8, plus other template and info entries
+	assert.Equal(t, 2+2+1+10, sink.len())
+	assert.NoError(t, logger.(Flusher).Flush())
+	assert.Equal(t, 16, sink.len())
+
+	summary := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(sink.entries[15]), &summary))
+	assert.Equal(t, "failed to get user %d (repeated 6 times)", summary["msg"])
+	assert.Equal(t, float64(6), summary[FieldRepeated])
+	assert.Equal(t, "error", summary["level"])
+
+	// nothing dropped since last flush
+	assert.NoError(t, logger.(Flusher).Flush())
+	assert.Equal(t, 16, sink.len())
+}
+
+func TestSamplingTick(t *testing.T) {
+	s := newSampler(SamplingConfig{
+		Tick:   time.Second,
+		Levels: map[log.Level]SamplingPolicy{log.WarnLevel: {First: 1}},
+		Dedup:  true,
+	})
+	now := time.Now()
+
+	ok, _ := s.check(log.WarnLevel, "msg", now)
+	assert.True(t, ok)
+	ok, _ = s.check(log.WarnLevel, "msg", now.Add(500*time.Millisecond))
+	assert.False(t, ok)
+
+	ok, summaries := s.check(log.WarnLevel, "msg", now.Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, []sampleSummary{{sampleKey: sampleKey{level: log.WarnLevel, template: "msg"}, dropped: 1}}, summaries)
+
+	ok, _ = s.check(log.FatalLevel, "msg", now)
+	assert.True(t, ok)
+}
+
+func TestSamplingSummaryAtTickEnd(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewSLogger("svc", WithSink(sink, log.DebugLevel), WithSampling(SamplingConfig{
+		Tick:   20 * time.Millisecond,
+		Levels: map[log.Level]SamplingPolicy{log.WarnLevel: {First: 1}},
+		Dedup:  true,
+	}))
+	defer logger.(Flusher).Close()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		logger.Warn(ctx, "slow query")
+	}
+	assert.Equal(t, 1, sink.len())
+
+	// the summary is logged once the tick ended, without another entry
+	deadline := time.Now().Add(time.Second)
+	for sink.len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, 2, sink.len())
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	summary := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(sink.entries[1]), &summary))
+	assert.Equal(t, "slow query (repeated 2 times)", summary["msg"])
+}
+
+func TestSamplingMarkDebug(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewSLogger("svc", WithSink(sink, log.DebugLevel), WithSampling(SamplingConfig{
+		Levels: map[log.Level]SamplingPolicy{log.InfoLevel: {First: 1}},
+	}))
+	marked := logger.BuildContextDataAndSetValue("ID", "ctx-debug")
+	other := logger.BuildContextDataAndSetValue("ID", "ctx-other")
+
+	MarkDebug("ctx-debug", time.Minute)
+	defer UnmarkDebug("ctx-debug")
+
+	for i := 0; i < 5; i++ {
+		logger.Info(marked, "hello")
+		logger.Info(other, "hello")
+	}
+	assert.Equal(t, 6, sink.len())
+
+	UnmarkDebug("ctx-debug")
+	logger.Info(marked, "hello")
+	assert.Equal(t, 6, sink.len())
+
+	MarkDebug("ctx-expired", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	assert.False(t, isDebugMarked(logger.BuildContextDataAndSetValue("ID", "ctx-expired")))
+	assert.False(t, isDebugMarked(context.Background()))
+}
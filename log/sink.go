@@ -0,0 +1,148 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Sink is the destination of formatted entries
+type Sink interface {
+	// Write writes one formatted entry
+	Write(level log.Level, p []byte) error
+	// Flush writes buffered entries to the underlying storage
+	Flush() error
+	// Close flushes and releases the sink
+	Close() error
+}
+
+type levelSink struct {
+	Sink
+	level log.Level
+}
+
+type writerSink struct {
+	w io.Writer
+}
+
+// NewWriterSink returns sink writing to w, w is closed by Close when it is an io.Closer
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+// NewStdoutSink returns sink writing to os.Stdout
+func NewStdoutSink() Sink {
+	return &writerSink{w: os.Stdout}
+}
+
+// NewStderrSink returns sink writing to os.Stderr
+func NewStderrSink() Sink {
+	return &writerSink{w: os.Stderr}
+}
+
+func (s *writerSink) Write(_ log.Level, p []byte) error {
+	_, err := s.w.Write(p)
+	return err
+}
+
+func (s *writerSink) Flush() error {
+	if syncer, ok := s.w.(interface{ Sync() error }); ok && s.w != os.Stdout && s.w != os.Stderr {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+func (s *writerSink) Close() error {
+	if closer, ok := s.w.(io.Closer); ok && s.w != os.Stdout && s.w != os.Stderr {
+		return closer.Close()
+	}
+	return nil
+}
+
+// sinkHook formats each entry once and writes it to every sink accepting its level,
+// entries enabled by forced debug or a caller level are written to every sink
+type sinkHook struct {
+	formatter log.Formatter
+	sinks     []*levelSink
+
+	// mu serializes writes of the logger and of its overridden logger, see newOverriddenEntry
+	mu sync.Mutex
+}
+
+func newSinkHook(c *config) *sinkHook {
+	sinks := c.sinks
+	if c.async != nil {
+		for i, sink := range sinks {
+			sinks[i] = &levelSink{Sink: NewAsyncSink(sink.Sink, *c.async), level: sink.level}
+		}
+	}
+
+	return &sinkHook{
+		formatter: &log.JSONFormatter{},
+		sinks:     sinks,
+	}
+}
+
+func (h *sinkHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *sinkHook) Fire(entry *log.Entry) error {
+	serialized, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// keep writing to the other sinks, only the first error is returned
+	overridden := isOverridden(entry)
+	for _, sink := range h.sinks {
+		if entry.Level <= sink.level || overridden {
+			if writeErr := sink.Write(entry.Level, serialized); writeErr != nil && err == nil {
+				err = writeErr
+			}
+		}
+	}
+	return err
+}
+
+// maxLevel is the most verbose level accepted by any sink
+func (h *sinkHook) maxLevel() log.Level {
+	level := log.PanicLevel
+	for _, sink := range h.sinks {
+		if sink.level > level {
+			level = sink.level
+		}
+	}
+	return level
+}
+
+func (h *sinkHook) flush() (err error) {
+	for _, sink := range h.sinks {
+		if flushErr := sink.Flush(); flushErr != nil && err == nil {
+			err = fmt.Errorf("flush sink: %w", flushErr)
+		}
+	}
+	return err
+}
+
+func (h *sinkHook) close() (err error) {
+	for _, sink := range h.sinks {
+		if closeErr := sink.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close sink: %w", closeErr)
+		}
+	}
+	return err
+}
+
+// nopFormatter is set on logger when sinks are used, since sinkHook already formats the entry
+type nopFormatter struct{}
+
+func (nopFormatter) Format(*log.Entry) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,174 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DropPolicy decides what happens to an entry written to a full async buffer
+type DropPolicy int
+
+const (
+	// DropNewest discards the entry being written
+	DropNewest = DropPolicy(iota)
+	// DropOldest discards the oldest buffered entry to make room
+	DropOldest
+	// Block waits until the buffer has room
+	Block
+)
+
+const defaultAsyncBufferSize = 4096
+
+var ErrSinkClosed = errors.New("log sink is closed")
+
+type AsyncConfig struct {
+	// BufferSize is the number of entries kept in the ring buffer, default is 4096
+	BufferSize int
+	DropPolicy DropPolicy
+}
+
+type asyncRecord struct {
+	level log.Level
+	p     []byte
+}
+
+// AsyncSink writes entries to the wrapped sink from a background goroutine
+type AsyncSink struct {
+	// dropped is accessed atomically, keep it first for 64-bit alignment
+	dropped uint64
+
+	sink   Sink
+	policy DropPolicy
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     []asyncRecord
+	head    int
+	size    int
+	writing bool
+	closed  bool
+	done    chan struct{}
+}
+
+// NewAsyncSink wraps sink with a ring buffer, so that Write never waits for sink unless policy is Block
+func NewAsyncSink(sink Sink, asyncConfig AsyncConfig) *AsyncSink {
+	if asyncConfig.BufferSize <= 0 {
+		asyncConfig.BufferSize = defaultAsyncBufferSize
+	}
+
+	s := &AsyncSink{
+		sink:   sink,
+		policy: asyncConfig.DropPolicy,
+		buf:    make([]asyncRecord, asyncConfig.BufferSize),
+		done:   make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+
+	return s
+}
+
+func (s *AsyncSink) Write(level log.Level, p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.size == len(s.buf) && !s.closed {
+		switch s.policy {
+		case DropOldest:
+			s.buf[s.head] = asyncRecord{}
+			s.head = (s.head + 1) % len(s.buf)
+			s.size--
+			atomic.AddUint64(&s.dropped, 1)
+		case Block:
+			s.cond.Wait()
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+			return nil
+		}
+	}
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	record := asyncRecord{level: level, p: make([]byte, len(p))}
+	copy(record.p, p)
+	s.buf[(s.head+s.size)%len(s.buf)] = record
+	s.size++
+	s.cond.Broadcast()
+
+	return nil
+}
+
+// Dropped returns number of entries discarded because the buffer was full
+func (s *AsyncSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Flush waits until every buffered entry is written, then flushes the wrapped sink
+func (s *AsyncSink) Flush() error {
+	s.mu.Lock()
+	for s.size > 0 || s.writing {
+		s.cond.Wait()
+	}
+	s.mu.Unlock()
+
+	return s.sink.Flush()
+}
+
+// Close writes the remaining entries and closes the wrapped sink
+func (s *AsyncSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrSinkClosed
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	<-s.done
+	return s.sink.Close()
+}
+
+func (s *AsyncSink) run() {
+	defer close(s.done)
+
+	batch := make([]asyncRecord, 0, len(s.buf))
+	for {
+		s.mu.Lock()
+		for s.size == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.size == 0 && s.closed {
+			s.mu.Unlock()
+			return
+		}
+
+		batch = batch[:0]
+		for s.size > 0 {
+			batch = append(batch, s.buf[s.head])
+			s.buf[s.head] = asyncRecord{}
+			s.head = (s.head + 1) % len(s.buf)
+			s.size--
+		}
+		s.writing = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		for _, record := range batch {
+			if err := s.sink.Write(record.level, record.p); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+			}
+		}
+
+		s.mu.Lock()
+		s.writing = false
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+}
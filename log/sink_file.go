@@ -0,0 +1,272 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+type FileSinkConfig struct {
+	// Filename is the file entries are written to, backups are kept in the same directory
+	Filename string
+	// MaxSize rotates the file before it grows bigger than MaxSize bytes, 0 disables size based rotation
+	MaxSize int64
+	// RotateEvery rotates the file on every multiple of RotateEvery, e.g. 24h rotates at midnight UTC, 0 disables time based rotation
+	RotateEvery time.Duration
+	// MaxBackups is the number of rotated files kept, 0 keeps all of them
+	MaxBackups int
+	// MaxAge removes rotated files older than MaxAge, 0 keeps all of them
+	MaxAge time.Duration
+	// Compress gzips rotated files
+	Compress bool
+}
+
+type fileSink struct {
+	cfg FileSinkConfig
+
+	mu         sync.Mutex
+	file       *os.File
+	closed     bool
+	size       int64
+	nextRotate time.Time
+
+	millWg sync.WaitGroup
+	millMu sync.Mutex
+}
+
+// NewFileSink returns sink writing to file with size and time based rotation
+func NewFileSink(fileSinkConfig FileSinkConfig) (Sink, error) {
+	if fileSinkConfig.Filename == "" {
+		return nil, fmt.Errorf("log file sink: empty filename")
+	}
+
+	s := &fileSink{cfg: fileSinkConfig}
+	if err := s.open(time.Now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) Write(_ log.Level, p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	now := time.Now()
+	var rotateErr error
+	if s.file == nil {
+		// the file was not reopened by the last rotation, retry
+		rotateErr = s.open(now)
+	} else if s.shouldRotate(now, int64(len(p))) {
+		rotateErr = s.rotate(now)
+	}
+	if s.file == nil {
+		return rotateErr
+	}
+
+	n, err := s.file.Write(p)
+	s.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return err
+}
+
+func (s *fileSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSinkClosed
+	}
+	s.closed = true
+
+	var err error
+	if s.file != nil {
+		err = s.file.Close()
+		s.file = nil
+	}
+	s.millWg.Wait()
+
+	return err
+}
+
+func (s *fileSink) shouldRotate(now time.Time, writeLen int64) bool {
+	if s.cfg.RotateEvery > 0 && !now.Before(s.nextRotate) {
+		if s.size > 0 {
+			return true
+		}
+		// nothing to rotate yet, wait for the next period
+		s.nextRotate = now.Truncate(s.cfg.RotateEvery).Add(s.cfg.RotateEvery)
+	}
+	return s.cfg.MaxSize > 0 && s.size > 0 && s.size+writeLen > s.cfg.MaxSize
+}
+
+func (s *fileSink) open(now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(s.cfg.Filename), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	s.file = file
+	s.size = info.Size()
+	if s.cfg.RotateEvery > 0 {
+		s.nextRotate = now.Truncate(s.cfg.RotateEvery).Add(s.cfg.RotateEvery)
+	}
+	return nil
+}
+
+// rotate renames the file to a backup and opens a new one. The file is reopened when renaming fails,
+// when opening fails the file is left nil and Write retries.
+func (s *fileSink) rotate(now time.Time) error {
+	err := s.file.Close()
+	s.file = nil
+
+	ext := filepath.Ext(s.cfg.Filename)
+	prefix := strings.TrimSuffix(s.cfg.Filename, ext)
+	backup := fmt.Sprintf("%s-%s%s", prefix, now.Format(backupTimeFormat), ext)
+	if err == nil {
+		err = os.Rename(s.cfg.Filename, backup)
+	}
+
+	if openErr := s.open(now); openErr != nil {
+		return openErr
+	}
+	if err != nil {
+		return err
+	}
+
+	s.millWg.Add(1)
+	go func() {
+		defer s.millWg.Done()
+		s.mill(backup)
+	}()
+	return nil
+}
+
+// mill compresses the new backup and removes backups exceeding MaxBackups or MaxAge
+func (s *fileSink) mill(backup string) {
+	s.millMu.Lock()
+	defer s.millMu.Unlock()
+
+	if s.cfg.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to compress log file, %v\n", err)
+		}
+	}
+
+	backups, err := s.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list log files, %v\n", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-s.cfg.MaxAge)
+	for i, b := range backups {
+		expired := s.cfg.MaxAge > 0 && b.ModTime().Before(cutoff)
+		exceeded := s.cfg.MaxBackups > 0 && i >= s.cfg.MaxBackups
+		if expired || exceeded {
+			os.Remove(filepath.Join(filepath.Dir(s.cfg.Filename), b.Name()))
+		}
+	}
+}
+
+// backups returns rotated files, newest first. Only names with a rotation time between prefix and extension are backups,
+// other files of the directory, e.g. app-audit.log next to app.log, are never removed.
+func (s *fileSink) backups() ([]os.FileInfo, error) {
+	dir := filepath.Dir(s.cfg.Filename)
+	ext := filepath.Ext(s.cfg.Filename)
+	prefix := strings.TrimSuffix(filepath.Base(s.cfg.Filename), ext) + "-"
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := f.Readdir(-1)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]os.FileInfo, 0, len(infos))
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, strings.TrimSuffix(stamp, ext)); err != nil {
+			continue
+		}
+		backups = append(backups, info)
+	}
+
+	// backup names embed the rotation time, so name order is time order
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name() > backups[j].Name()
+	})
+	return backups, nil
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		src.Close()
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	src.Close()
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+
+	return os.Remove(name)
+}
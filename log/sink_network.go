@@ -0,0 +1,71 @@
+package log
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultDialTimeout = 5 * time.Second
+
+type networkSink struct {
+	network string
+	addr    string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewNetworkSink returns sink forwarding every entry to addr over "tcp" or "udp".
+// Connection is dialed on first write and redialed after a failed write.
+func NewNetworkSink(network, addr string, timeout time.Duration) Sink {
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+
+	return &networkSink{
+		network: network,
+		addr:    addr,
+		timeout: timeout,
+	}
+}
+
+func (s *networkSink) Write(_ log.Level, p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.addr, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if _, err := s.conn.Write(p); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *networkSink) Flush() error {
+	return nil
+}
+
+func (s *networkSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,51 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package log
+
+import (
+	"log/syslog"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink returns sink forwarding entries to syslog, severity follows the entry level.
+// Empty network and raddr connect to the local syslog server.
+func NewSyslogSink(network, raddr, tag string) (Sink, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_LOCAL0, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(level log.Level, p []byte) error {
+	message := string(p)
+
+	switch level {
+	case log.PanicLevel:
+		return s.w.Emerg(message)
+	case log.FatalLevel:
+		return s.w.Crit(message)
+	case log.ErrorLevel:
+		return s.w.Err(message)
+	case log.WarnLevel:
+		return s.w.Warning(message)
+	case log.InfoLevel:
+		return s.w.Info(message)
+	default:
+		return s.w.Debug(message)
+	}
+}
+
+func (s *syslogSink) Flush() error {
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
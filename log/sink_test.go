@@ -0,0 +1,299 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/assert"
+	log "github.com/sirupsen/logrus"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	entries []string
+	levels  []log.Level
+	delay   time.Duration
+	closed  bool
+}
+
+func (s *memorySink) Write(level log.Level, p []byte) error {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, string(p))
+	s.levels = append(s.levels, level)
+	return nil
+}
+
+func (s *memorySink) Flush() error {
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *memorySink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func TestSinkPerLevel(t *testing.T) {
+	all := &memorySink{}
+	errorOnly := &memorySink{}
+	logger := NewSLogger("svc", WithSink(all, log.DebugLevel), WithSink(errorOnly, log.ErrorLevel))
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+
+	logger.Debug(ctx, "debug")
+	logger.Info(ctx, "info")
+	logger.Errorf(ctx, "error %d", 1)
+
+	assert.Equal(t, 3, all.len())
+	assert.Equal(t, 1, errorOnly.len())
+	assert.Equal(t, log.ErrorLevel, errorOnly.levels[0])
+
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(errorOnly.entries[0]), &entry))
+	assert.Equal(t, "error 1", entry["msg"])
+	assert.Equal(t, "svc", entry["service"])
+	assert.Equal(t, "ctx-1", entry[ContextIdKey])
+
+	assert.NoError(t, logger.(Flusher).Close())
+	assert.True(t, all.closed)
+	assert.True(t, errorOnly.closed)
+}
+
+func TestSinkOverriddenEntries(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewSLogger("svc", WithSink(sink, log.InfoLevel))
+	ctx := context.Background()
+
+	logger.Debug(ctx, "dropped")
+	logger.Debug(WithForcedDebug(ctx), "forced")
+	logger.(LevelController).SetCallerLevel("testing", log.DebugLevel)
+	logger.Debug(ctx, "caller")
+
+	assert.Equal(t, 2, sink.len())
+	assert.Equal(t, []log.Level{log.DebugLevel, log.DebugLevel}, sink.levels)
+	assert.Equal(t, log.InfoLevel, logger.(LevelController).GetLevel())
+}
+
+// levelRecordingSink records the level of logger while entries are written
+type levelRecordingSink struct {
+	memorySink
+	logger SLogger
+	seen   []log.Level
+}
+
+func (s *levelRecordingSink) Write(level log.Level, p []byte) error {
+	s.seen = append(s.seen, s.logger.(LevelController).GetLevel())
+	return s.memorySink.Write(level, p)
+}
+
+func TestSinkOverriddenEntriesKeepLevel(t *testing.T) {
+	sink := &levelRecordingSink{}
+	sink.logger = NewSLogger("svc", WithSink(sink, log.InfoLevel))
+
+	sink.logger.Debug(WithForcedDebug(context.Background()), "forced")
+
+	assert.Equal(t, 1, sink.len())
+	assert.Equal(t, []log.Level{log.InfoLevel}, sink.seen)
+}
+
+func TestSinkOverriddenEntriesSerialized(t *testing.T) {
+	// bytes.Buffer is not safe for concurrent writes, the race detector reports unserialized entries
+	var buf bytes.Buffer
+	logger := NewSLogger("svc", WithSink(NewWriterSink(&buf), log.InfoLevel))
+	forced := WithForcedDebug(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			logger.Info(context.Background(), "info")
+		}()
+		go func() {
+			defer wg.Done()
+			logger.Debug(forced, "forced")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, strings.Count(buf.String(), "\n"))
+	assert.Equal(t, 50, strings.Count(buf.String(), `"level":"debug"`))
+}
+
+func TestFatalClosesSinks(t *testing.T) {
+	sink := &memorySink{delay: 10 * time.Millisecond}
+	logger := NewSLogger("svc", WithSink(sink, log.InfoLevel), WithAsync(AsyncConfig{}))
+	exitCode := -1
+	osExit = func(code int) { exitCode = code }
+	defer func() { osExit = os.Exit }()
+
+	logger.Info(context.Background(), "queued")
+	logger.Fatal(context.Background(), "fatal")
+
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, 2, sink.len())
+	assert.True(t, sink.closed)
+}
+
+func TestWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithSink(NewWriterSink(buf), log.InfoLevel))
+
+	logger.Info(context.Background(), "hello")
+	assert.True(t, strings.Contains(buf.String(), `"msg":"hello"`))
+	assert.NoError(t, logger.(Flusher).Flush())
+}
+
+func TestAsyncSink(t *testing.T) {
+	sink := &memorySink{}
+	logger := NewSLogger("svc", WithSink(sink, log.InfoLevel), WithAsync(AsyncConfig{BufferSize: 16, DropPolicy: Block}))
+
+	for i := 0; i < 100; i++ {
+		logger.Infof(context.Background(), "entry %d", i)
+	}
+	assert.NoError(t, logger.(Flusher).Flush())
+	assert.Equal(t, 100, sink.len())
+	assert.NoError(t, logger.(Flusher).Close())
+	assert.True(t, sink.closed)
+}
+
+func TestAsyncSinkDropPolicy(t *testing.T) {
+	slow := &memorySink{delay: 10 * time.Millisecond}
+	async := NewAsyncSink(slow, AsyncConfig{BufferSize: 2, DropPolicy: DropOldest})
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, async.Write(log.InfoLevel, []byte{byte('0' + i)}))
+	}
+	assert.NoError(t, async.Close())
+
+	assert.True(t, async.Dropped() > 0)
+	assert.Equal(t, uint64(10), uint64(slow.len())+async.Dropped())
+	// the newest entry is always kept by DropOldest
+	assert.Equal(t, "9", slow.entries[len(slow.entries)-1])
+	assert.Equal(t, ErrSinkClosed, async.Write(log.InfoLevel, []byte("late")))
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-sink")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	sink, err := NewFileSink(FileSinkConfig{
+		Filename:   filename,
+		MaxSize:    10,
+		MaxBackups: 2,
+		Compress:   true,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, sink.Write(log.InfoLevel, []byte("0123456789")))
+		time.Sleep(2 * time.Millisecond) // backup names have millisecond precision
+	}
+	assert.NoError(t, sink.Close())
+
+	current, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(current))
+
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(backups))
+}
+
+func TestFileSinkKeepsOtherFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-sink")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	others := []string{"app-audit.log", "app-audit.log.gz", "app-2006-01-02.log"}
+	for _, name := range others {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("other"), 0644))
+	}
+
+	sink, err := NewFileSink(FileSinkConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    10,
+		MaxBackups: 1,
+	})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, sink.Write(log.InfoLevel, []byte("0123456789")))
+		time.Sleep(2 * time.Millisecond) // backup names have millisecond precision
+	}
+	assert.NoError(t, sink.Close())
+
+	for _, name := range others {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err)
+	}
+	backups, err := sink.(*fileSink).backups()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(backups))
+}
+
+func TestFileSinkRotationFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-sink")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	sink, err := NewFileSink(FileSinkConfig{Filename: filename})
+	assert.NoError(t, err)
+	fs := sink.(*fileSink)
+
+	// a directory at the backup name makes renaming fail, the file is reopened
+	now := time.Now()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "app-"+now.Format(backupTimeFormat)+".log", "taken"), 0755))
+	assert.NoError(t, sink.Write(log.InfoLevel, []byte("before\n")))
+	assert.Error(t, fs.rotate(now))
+	assert.NoError(t, sink.Write(log.InfoLevel, []byte("after rename\n")))
+
+	// a file not reopened by the last rotation is opened by the next write
+	fs.file.Close()
+	fs.file = nil
+	assert.NoError(t, sink.Write(log.InfoLevel, []byte("after open\n")))
+	assert.NoError(t, sink.Close())
+	assert.Equal(t, ErrSinkClosed, sink.Write(log.InfoLevel, []byte("closed\n")))
+
+	current, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "before\nafter rename\nafter open\n", string(current))
+}
+
+func TestFileSinkTimeRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-sink")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	sink, err := NewFileSink(FileSinkConfig{
+		Filename:    filename,
+		RotateEvery: 10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, sink.Write(log.InfoLevel, []byte("first\n")))
+	time.Sleep(15 * time.Millisecond)
+	assert.NoError(t, sink.Write(log.InfoLevel, []byte("second\n")))
+	assert.NoError(t, sink.Close())
+
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(backups))
+}
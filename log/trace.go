@@ -0,0 +1,33 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FieldTraceID    = "trace_id"
+	FieldSpanID     = "span_id"
+	FieldTraceFlags = "trace_flags"
+)
+
+// TraceFields returns trace id, span id and trace flags of the OpenTelemetry span in ctx, nil when ctx has no span
+func TraceFields(ctx context.Context) map[string]string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	return map[string]string{
+		FieldTraceID:    spanContext.TraceID().String(),
+		FieldSpanID:     spanContext.SpanID().String(),
+		FieldTraceFlags: spanContext.TraceFlags().String(),
+	}
+}
+
+func (f fields) getFieldsFromSpan(ctx context.Context) {
+	for key, value := range TraceFields(ctx) {
+		f[key] = value
+	}
+}
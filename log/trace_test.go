@@ -0,0 +1,31 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/c2fo/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceFields(t *testing.T) {
+	logger, hook := NewSLoggerWithTestHook("svc")
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(logger.BuildContextDataAndSetValue("ID", "ctx-1"), spanContext)
+
+	logger.Errorf(ctx, "failed %d", 1)
+	data := hook.LastEntry().Data
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", data[FieldTraceID])
+	assert.Equal(t, "00f067aa0ba902b7", data[FieldSpanID])
+	assert.Equal(t, "01", data[FieldTraceFlags])
+	assert.Equal(t, "ctx-1", data[ContextIdKey])
+
+	logger.Info(context.Background(), "no span")
+	assert.Nil(t, hook.LastEntry().Data[FieldTraceID])
+	assert.Nil(t, TraceFields(context.Background()))
+}
@@ -4,6 +4,6 @@ go 1.13
 
 require (
 	github.com/c2fo/testify v0.0.0-20150827203832-fba96363964a
+	github.com/muhammad-fakhri/go-libs/log v1.1.0
 	github.com/sirupsen/logrus v1.4.2
-	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd // indirect
 )
@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	logv1 "github.com/muhammad-fakhri/go-libs/log"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -41,13 +42,10 @@ type SLogger interface {
 	LogResponse(ctx context.Context, rw *loggingResponseWriter)
 }
 
-// safe typing https://golang.org/pkg/context/#WithValue
-type contextDataMapKeyType string
-
 // add key here for future request based value
 var (
-	// data map to contain values
-	ContextDataMapKey contextDataMapKeyType = "value"
+	// data map to contain values, shared with v1 so that context built by either version is read by both
+	ContextDataMapKey = logv1.ContextDataMapKey
 
 	// context key data added to map
 	ContextCountryKey = "country"
@@ -288,6 +286,10 @@ func (lp *LogParams) injectContextDataMap(ctx context.Context) *LogParams {
 		}
 	}
 
+	for key, value := range logv1.ContextFields(ctx) {
+		lp.fields[key] = value
+	}
+
 	return lp
 }
 
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/c2fo/testify/assert"
+	logv1 "github.com/muhammad-fakhri/go-libs/log"
 )
 
 type obj struct {
@@ -135,3 +136,18 @@ func TestNoCollisionWhenBuildContextData(t *testing.T) {
 	assert.Equal(t, randomCountry, contextDataFromLogger[ContextCountryKey])
 	assert.Equal(t, randomID, contextDataFromLogger[ContextIdKey])
 }
+
+func TestReadsV1ContextAndFields(t *testing.T) {
+	v1 := logv1.NewSLogger("svc")
+	logger, hook := NewSLoggerWithTestHook("svc")
+
+	ctx := v1.BuildContextDataAndSetValue("ID", "ctx-1")
+	ctx = logv1.WithFields(ctx, logv1.Int("order_id", 5), logv1.Bool("paid", true))
+
+	logger.Info(ctx, "hello")
+	data := hook.LastEntry().Data
+	assert.Equal(t, "ID", data[ContextCountryKey])
+	assert.Equal(t, "ctx-1", data[ContextIdKey])
+	assert.Equal(t, 5, data["order_id"])
+	assert.Equal(t, true, data["paid"])
+}
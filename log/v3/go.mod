@@ -0,0 +1,16 @@
+module github.com/muhammad-fakhri/go-libs/log/v3
+
+go 1.21
+
+require (
+	github.com/c2fo/testify v0.0.0-20150827203832-fba96363964a
+	github.com/muhammad-fakhri/go-libs/log v1.1.0
+	go.opentelemetry.io/otel/trace v1.7.0
+)
+
+require (
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/sirupsen/logrus v1.4.2 // indirect
+	go.opentelemetry.io/otel v1.7.0 // indirect
+	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd // indirect
+)
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	consoleTimeFormat = "2006-01-02 15:04:05.000"
+
+	colorReset  = "\033[0m"
+	colorGray   = "\033[90m"
+	colorBlue   = "\033[34m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+)
+
+// consoleHandler writes human readable lines, e.g.
+// 2006-01-02 15:04:05.000 INFO  message service=svc country=ID
+type consoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	color  bool
+	prefix string
+	attrs  []byte
+}
+
+func newConsoleHandler(w io.Writer, level slog.Leveler, color bool) *consoleHandler {
+	return &consoleHandler{
+		mu:    &sync.Mutex{},
+		w:     w,
+		level: level,
+		color: color,
+	}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	buf := &bytes.Buffer{}
+
+	if !r.Time.IsZero() {
+		buf.WriteString(r.Time.Format(consoleTimeFormat))
+		buf.WriteByte(' ')
+	}
+	h.writeLevel(buf, r.Level)
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+	buf.Write(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendConsoleAttr(buf, h.prefix, a)
+		return true
+	})
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	buf := bytes.NewBuffer(append([]byte{}, h.attrs...))
+	for _, a := range attrs {
+		appendConsoleAttr(buf, h.prefix, a)
+	}
+
+	clone := *h
+	clone.attrs = buf.Bytes()
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func (h *consoleHandler) writeLevel(buf *bytes.Buffer, level slog.Level) {
+	name := fmt.Sprintf("%-5s", strings.ToUpper(levelName(level)))
+	if !h.color {
+		buf.WriteString(name)
+		return
+	}
+
+	color := colorGray
+	switch {
+	case level >= slog.LevelError:
+		color = colorRed
+	case level >= slog.LevelWarn:
+		color = colorYellow
+	case level >= slog.LevelInfo:
+		color = colorBlue
+	}
+	buf.WriteString(color + name + colorReset)
+}
+
+func appendConsoleAttr(buf *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendConsoleAttr(buf, groupPrefix, ga)
+		}
+		return
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(prefix + a.Key)
+	buf.WriteByte('=')
+	buf.WriteString(consoleValue(a.Value))
+}
+
+func consoleValue(v slog.Value) string {
+	var s string
+	switch v.Kind() {
+	case slog.KindString:
+		s = v.String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			s = err.Error()
+		} else {
+			s = fmt.Sprintf("%+v", v.Any())
+		}
+	default:
+		return v.String()
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,340 @@
+// Package log is the SLogger implementation built on top of log/slog handlers
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	logv1 "github.com/muhammad-fakhri/go-libs/log"
+)
+
+// SLogger has the v1 logging methods, with GetLogger and SetLevel using log/slog types. See README.md for the differences
+type SLogger interface {
+	BuildContextDataAndSetValue(country string, contextID string) (cctx context.Context)
+	SetContextDataAndSetValue(r *http.Request, data map[string]string, country string, contextId string) *http.Request
+	GetLogger() *slog.Logger
+	Infof(ctx context.Context, message string, args ...interface{})
+	Errorf(ctx context.Context, message string, args ...interface{})
+	Warnf(ctx context.Context, message string, args ...interface{})
+	Debugf(ctx context.Context, message string, args ...interface{})
+	Fatalf(ctx context.Context, message string, args ...interface{})
+	Info(ctx context.Context, args ...interface{})
+	Error(ctx context.Context, args ...interface{})
+	Warn(ctx context.Context, args ...interface{})
+	Debug(ctx context.Context, args ...interface{})
+	Fatal(ctx context.Context, args ...interface{})
+
+	InfoMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{})
+	ErrorMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{})
+
+	LogRequestResponse(ctx context.Context, data *RequestResponse, args ...interface{})
+	SetContextData(ctx context.Context, data *CommonFields) (cctx context.Context)
+
+	SetLevel(level slog.Level)
+}
+
+// RequestResponse, CommonFields and Field are shared with v1 so callers can switch versions without conversion
+type (
+	RequestResponse = logv1.RequestResponse
+	CommonFields    = logv1.CommonFields
+	Field           = logv1.Field
+)
+
+// typed field bag shared with v1, see logv1.WithFields
+var (
+	WithFields    = logv1.WithFields
+	ContextFields = logv1.ContextFields
+	Str           = logv1.Str
+	Int           = logv1.Int
+	Int64         = logv1.Int64
+	Uint64        = logv1.Uint64
+	Float64       = logv1.Float64
+	Bool          = logv1.Bool
+	Duration      = logv1.Duration
+	Time          = logv1.Time
+	Err           = logv1.Err
+	Any           = logv1.Any
+)
+
+// add key here for future request based value
+var (
+	// data map to contain values, shared by every version so that they read each other's context
+	ContextDataMapKey = logv1.ContextDataMapKey
+
+	// context key data added to map
+	ContextCountryKey = logv1.ContextCountryKey
+	ContextIdKey      = logv1.ContextIdKey
+	ContextUserIdKey  = logv1.ContextUserIdKey
+	ContextEventIdKey = logv1.ContextEventIdKey
+)
+
+// Format is the output format of the built-in handlers
+type Format int
+
+const (
+	FormatJSON = Format(iota)
+	FormatLogfmt
+	FormatConsole
+)
+
+// Option initializes configs
+type Option func(c *config)
+
+type config struct {
+	format  Format
+	writer  io.Writer
+	level   slog.Level
+	color   bool
+	handler slog.Handler
+}
+
+// WithFormat sets output format of the built-in handler, default is FormatJSON
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
+// WithWriter sets output of the built-in handler, default is os.Stderr
+func WithWriter(w io.Writer) Option {
+	return func(c *config) {
+		c.writer = w
+	}
+}
+
+// WithLevel sets minimum level to be logged, default is slog.LevelInfo
+func WithLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithColor enables ANSI colored level on FormatConsole
+func WithColor(color bool) Option {
+	return func(c *config) {
+		c.color = color
+	}
+}
+
+// WithHandler injects any slog.Handler, format, writer and color options are ignored
+func WithHandler(handler slog.Handler) Option {
+	return func(c *config) {
+		c.handler = handler
+	}
+}
+
+type SLog struct {
+	handler slog.Handler
+	level   *slog.LevelVar
+}
+
+const (
+	fieldService = "service"
+	fieldFunc    = "func"
+	fieldFile    = "file"
+)
+
+func NewSLogger(service string, options ...Option) SLogger {
+	c := &config{
+		writer: os.Stderr,
+		level:  slog.LevelInfo,
+	}
+	for _, o := range options {
+		o(c)
+	}
+
+	level := &slog.LevelVar{}
+	level.Set(c.level)
+
+	handler := c.handler
+	if handler == nil {
+		handler = newHandler(c, level)
+	}
+
+	return &SLog{
+		handler: handler.WithAttrs([]slog.Attr{slog.String(fieldService, service)}),
+		level:   level,
+	}
+}
+
+func newHandler(c *config, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: lowerLevel,
+	}
+
+	switch c.format {
+	case FormatLogfmt:
+		return slog.NewTextHandler(c.writer, opts)
+	case FormatConsole:
+		return newConsoleHandler(c.writer, level, c.color)
+	default:
+		return slog.NewJSONHandler(c.writer, opts)
+	}
+}
+
+// lowerLevel keeps level value the same as the logrus based v1 and v2, e.g. "info"
+func lowerLevel(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String(slog.LevelKey, levelName(level))
+		}
+	}
+	return a
+}
+
+func levelName(level slog.Level) string {
+	if level >= LevelFatal {
+		return "fatal"
+	}
+	return strings.ToLower(level.String())
+}
+
+func (l *SLog) BuildContextDataAndSetValue(country string, contextId string) (ctx context.Context) {
+	data := make(map[string]string, 0)
+	data[ContextCountryKey] = country
+	data[ContextIdKey] = contextId
+
+	return withContextData(context.Background(), data)
+}
+
+func (l *SLog) SetContextDataAndSetValue(r *http.Request, data map[string]string, country string, contextId string) *http.Request {
+	if data == nil {
+		data = make(map[string]string, 0)
+	}
+	data[ContextCountryKey] = country
+	data[ContextIdKey] = contextId
+
+	return r.WithContext(withContextData(r.Context(), data))
+}
+
+// SetContextData merges data over the context data already in ctx
+func (l *SLog) SetContextData(ctx context.Context, data *CommonFields) (cctx context.Context) {
+	cctx = logv1.WithContextData(ctx, data.ToDataMap())
+	if len(data.Fields) > 0 {
+		cctx = WithFields(cctx, data.Fields...)
+	}
+	return cctx
+}
+
+func withContextData(ctx context.Context, data map[string]string) context.Context {
+	return context.WithValue(ctx, ContextDataMapKey, data)
+}
+
+func getContextData(ctx context.Context) map[string]string {
+	data, _ := ctx.Value(ContextDataMapKey).(map[string]string)
+	return data
+}
+
+func (l *SLog) GetLogger() *slog.Logger {
+	return slog.New(l.handler)
+}
+
+func (l *SLog) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+func (l *SLog) Infof(ctx context.Context, message string, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, nil, fmt.Sprintf(message, args...))
+}
+
+func (l *SLog) Errorf(ctx context.Context, message string, args ...interface{}) {
+	l.log(ctx, slog.LevelError, nil, fmt.Sprintf(message, args...))
+}
+
+func (l *SLog) Warnf(ctx context.Context, message string, args ...interface{}) {
+	l.log(ctx, slog.LevelWarn, nil, fmt.Sprintf(message, args...))
+}
+
+func (l *SLog) Debugf(ctx context.Context, message string, args ...interface{}) {
+	l.log(ctx, slog.LevelDebug, nil, fmt.Sprintf(message, args...))
+}
+
+func (l *SLog) Fatalf(ctx context.Context, message string, args ...interface{}) {
+	l.log(ctx, LevelFatal, nil, fmt.Sprintf(message, args...))
+	os.Exit(1)
+}
+
+func (l *SLog) Info(ctx context.Context, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, nil, fmt.Sprint(args...))
+}
+
+func (l *SLog) Error(ctx context.Context, args ...interface{}) {
+	l.log(ctx, slog.LevelError, nil, fmt.Sprint(args...))
+}
+
+func (l *SLog) Warn(ctx context.Context, args ...interface{}) {
+	l.log(ctx, slog.LevelWarn, nil, fmt.Sprint(args...))
+}
+
+func (l *SLog) Debug(ctx context.Context, args ...interface{}) {
+	l.log(ctx, slog.LevelDebug, nil, fmt.Sprint(args...))
+}
+
+func (l *SLog) Fatal(ctx context.Context, args ...interface{}) {
+	l.log(ctx, LevelFatal, nil, fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+func (l *SLog) InfoMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, dataMap, fmt.Sprint(args...))
+}
+
+func (l *SLog) ErrorMap(ctx context.Context, dataMap map[string]interface{}, args ...interface{}) {
+	l.log(ctx, slog.LevelError, dataMap, fmt.Sprint(args...))
+}
+
+func (l *SLog) LogRequestResponse(ctx context.Context, data *RequestResponse, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, data.ToDataMap(), fmt.Sprint(args...))
+}
+
+// LevelFatal is logged by Fatal and Fatalf before exiting
+const LevelFatal = slog.Level(12)
+
+// callerSkip skips runtime.Callers, log and the exported level method
+const callerSkip = 3
+
+func (l *SLog) log(ctx context.Context, level slog.Level, dataMap map[string]interface{}, message string) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if level < l.level.Level() || !l.handler.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(callerSkip, pcs[:])
+	record := slog.NewRecord(time.Now(), level, message, pcs[0])
+
+	if level >= slog.LevelError { // only print func and file on level higher or equal error
+		frame, _ := runtime.CallersFrames(pcs[:]).Next()
+		if frame.Function != "" {
+			record.AddAttrs(slog.String(fieldFunc, frame.Function))
+		}
+		if frame.File != "" {
+			record.AddAttrs(slog.String(fieldFile, fmt.Sprintf("%s:%d", frame.File, frame.Line)))
+		}
+	}
+
+	for key, value := range getContextData(ctx) {
+		record.AddAttrs(slog.String(key, value))
+	}
+	for key, value := range ContextFields(ctx) {
+		record.AddAttrs(slog.Any(key, value))
+	}
+	for key, value := range logv1.TraceFields(ctx) {
+		record.AddAttrs(slog.String(key, value))
+	}
+	for key, value := range dataMap {
+		record.AddAttrs(slog.Any(key, value))
+	}
+
+	_ = l.handler.Handle(ctx, record)
+}
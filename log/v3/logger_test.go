@@ -0,0 +1,160 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/c2fo/testify/assert"
+	logv1 "github.com/muhammad-fakhri/go-libs/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	sampleString = "some string with a somewhat realistic length"
+	sampleMap    = map[string]interface{}{"url": "be-service/users", "method": "GET", "status": 200}
+)
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	line := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	buf.Reset()
+	return line
+}
+
+func BenchmarkSLog_InfoSimple(b *testing.B) {
+	logger := NewSLogger(sampleString, WithWriter(&bytes.Buffer{}))
+	ctx := logger.BuildContextDataAndSetValue("Japan", "11")
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		logger.Info(ctx, sampleString)
+	}
+}
+
+func BenchmarkSLog_InfoWithMap(b *testing.B) {
+	logger := NewSLogger(sampleString, WithWriter(&bytes.Buffer{}))
+	ctx := logger.BuildContextDataAndSetValue("Japan", "11")
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		logger.InfoMap(ctx, sampleMap)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithWriter(buf))
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+
+	logger.Infof(ctx, "hello %s", "world")
+	line := decodeLine(t, buf)
+	assert.Equal(t, "hello world", line["msg"])
+	assert.Equal(t, "info", line["level"])
+	assert.Equal(t, "svc", line["service"])
+	assert.Equal(t, "ID", line[ContextCountryKey])
+	assert.Equal(t, "ctx-1", line[ContextIdKey])
+	assert.Nil(t, line["func"])
+
+	logger.ErrorMap(ctx, sampleMap, "failed")
+	line = decodeLine(t, buf)
+	assert.Equal(t, "error", line["level"])
+	assert.Equal(t, "GET", line["method"])
+	assert.Equal(t, float64(200), line["status"])
+	assert.True(t, strings.HasSuffix(line["func"].(string), "TestJSONFormat"))
+	assert.True(t, strings.Contains(line["file"].(string), "logger_test.go"))
+}
+
+func TestLogfmtFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithWriter(buf), WithFormat(FormatLogfmt))
+
+	logger.Warn(logger.BuildContextDataAndSetValue("ID", "ctx-1"), "slow query")
+	out := buf.String()
+	assert.True(t, strings.Contains(out, `level=warn msg="slow query" service=svc`))
+	assert.True(t, strings.Contains(out, "context_id=ctx-1"))
+}
+
+func TestConsoleFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithWriter(buf), WithFormat(FormatConsole))
+
+	logger.InfoMap(context.Background(), map[string]interface{}{"path": "/users list"}, "request")
+	out := buf.String()
+	assert.True(t, strings.Contains(out, `INFO  request service=svc path="/users list"`))
+	assert.True(t, strings.HasSuffix(out, "\n"))
+}
+
+func TestSetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithWriter(buf))
+
+	logger.Debug(context.Background(), "hidden")
+	assert.Equal(t, 0, buf.Len())
+
+	logger.SetLevel(slog.LevelDebug)
+	logger.Debug(context.Background(), "shown")
+	assert.Equal(t, "shown", decodeLine(t, buf)["msg"])
+}
+
+func TestWithHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithHandler(slog.NewJSONHandler(buf, nil)))
+
+	logger.Info(context.Background(), "custom")
+	line := decodeLine(t, buf)
+	assert.Equal(t, "custom", line["msg"])
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "svc", line["service"])
+}
+
+func TestContextCompatibility(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithWriter(buf))
+
+	// contexts built by v1 are read by v3, v2 shares the v1 context keys
+	logger.Info(logv1.NewSLogger("v1").BuildContextDataAndSetValue("SG", "from-v1"), "v1")
+	assert.Equal(t, "from-v1", decodeLine(t, buf)[ContextIdKey])
+
+	// contexts built by v3 are read by v1 and v2
+	ctx := logger.SetContextData(context.Background(), &CommonFields{ContextID: "from-v3", UserID: "1"})
+	assert.Equal(t, "from-v3", ctx.Value(logv1.ContextDataMapKey).(map[string]string)[logv1.ContextIdKey])
+}
+
+func TestTraceFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithWriter(buf))
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(logger.BuildContextDataAndSetValue("ID", "ctx-1"), spanContext)
+
+	logger.Info(ctx, "hello")
+	line := decodeLine(t, buf)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line[logv1.FieldTraceID])
+	assert.Equal(t, "00f067aa0ba902b7", line[logv1.FieldSpanID])
+	assert.Equal(t, "01", line[logv1.FieldTraceFlags])
+}
+
+func TestWithFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSLogger("svc", WithWriter(buf))
+
+	ctx := logger.BuildContextDataAndSetValue("ID", "ctx-1")
+	ctx = WithFields(ctx, Int("order_id", 5), Bool("paid", true))
+	ctx = logger.SetContextData(ctx, &CommonFields{UserID: "42", Fields: []Field{Str("status", "paid")}})
+
+	logger.Info(ctx, "hello")
+	line := decodeLine(t, buf)
+	assert.Equal(t, float64(5), line["order_id"])
+	assert.Equal(t, true, line["paid"])
+	assert.Equal(t, "paid", line["status"])
+	assert.Equal(t, "42", line[ContextUserIdKey])
+	assert.Equal(t, "ctx-1", line[ContextIdKey])
+}